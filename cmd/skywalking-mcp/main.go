@@ -57,6 +57,8 @@ func init() {
 
 	// Add global Flags
 	rootCmd.PersistentFlags().String("sw-url", "", "Specify the OAP URL to connect to (e.g. http://localhost:12800)")
+	rootCmd.PersistentFlags().String("zipkin-url", "",
+		"Specify the Zipkin query API URL of OAP (e.g. http://localhost:9412/zipkin), derived from the OAP URL by default")
 	rootCmd.PersistentFlags().String("log-level", "info", "Logging level (debug, info, warn, error)")
 	rootCmd.PersistentFlags().Bool("read-only", false, "Restrict the server to read-only operations")
 	rootCmd.PersistentFlags().Bool("log-command", false, "When true, log commands to the log file")
@@ -64,6 +66,7 @@ func init() {
 
 	// Bind flag to viper
 	_ = viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("sw-url"))
+	_ = viper.BindPFlag("zipkin-url", rootCmd.PersistentFlags().Lookup("zipkin-url"))
 	_ = viper.BindPFlag("log-level", rootCmd.PersistentFlags().Lookup("log-level"))
 	_ = viper.BindPFlag("read-only", rootCmd.PersistentFlags().Lookup("read-only"))
 	_ = viper.BindPFlag("log-command", rootCmd.PersistentFlags().Lookup("log-command"))
@@ -79,6 +82,7 @@ func init() {
 	// Add subcommands
 	rootCmd.AddCommand(swmcp.NewStdioServer())
 	rootCmd.AddCommand(swmcp.NewSSEServer())
+	rootCmd.AddCommand(swmcp.NewExportTraceCommand())
 }
 
 func main() {
@@ -4,6 +4,7 @@ go 1.24.3
 
 require (
 	github.com/apache/skywalking-cli v0.0.0-20250604010708-77b4c49e89c9
+	github.com/machinebox/graphql v0.2.2
 	github.com/mark3labs/mcp-go v0.31.0
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/cobra v1.9.1
@@ -16,7 +17,6 @@ require (
 	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/machinebox/graphql v0.2.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/sagikazarmark/locafero v0.9.0 // indirect
@@ -0,0 +1,60 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package swmcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-mcp/internal/tools"
+)
+
+func NewExportTraceCommand() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export-trace <trace-id>",
+		Short: "Export a trace",
+		Long:  `Export a trace from OAP as OTLP JSON, Jaeger JSON, SkyWalking JSON or an indented span tree.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			output, _ := cmd.Flags().GetString("output")
+
+			ctx := ExtractSWURLFromCfg(context.Background())
+			text, err := tools.RenderTrace(ctx, args[0], format)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err = fmt.Fprintln(os.Stdout, text)
+				return err
+			}
+			return os.WriteFile(output, []byte(text), 0o600)
+		},
+	}
+
+	exportCmd.Flags().String("format", tools.FormatOTLP,
+		"The export format (otlp, jaeger, json, tree)")
+	exportCmd.Flags().String("output", "",
+		"Path of the file to write the trace to, defaults to stdout")
+
+	return exportCmd
+}
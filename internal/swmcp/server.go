@@ -36,7 +36,11 @@ func newMcpServer() *server.MCPServer {
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging())
 
+	tools.AddMetadataTools(mcpServer)
 	tools.AddTraceTools(mcpServer)
+	tools.AddMetricsTools(mcpServer)
+	tools.AddLogTools(mcpServer)
+	tools.AddZipkinTools(mcpServer)
 
 	return mcpServer
 }
@@ -138,6 +138,24 @@ var ExtractSWURLFromHeaders server.SSEContextFunc = func(ctx context.Context, re
 	return WithSkyWalkingURLAndInsecure(ctx, urlStr)
 }
 
+var ExtractZipkinURLFromCfg server.StdioContextFunc = func(ctx context.Context) context.Context {
+	if urlStr := viper.GetString("zipkin-url"); urlStr != "" {
+		ctx = tools.WithZipkinURL(ctx, urlStr)
+	}
+	return ctx
+}
+
+var ExtractZipkinURLFromHeaders server.SSEContextFunc = func(ctx context.Context, req *http.Request) context.Context {
+	urlStr := req.Header.Get("SW-Zipkin-URL")
+	if urlStr == "" {
+		urlStr = viper.GetString("zipkin-url")
+	}
+	if urlStr != "" {
+		ctx = tools.WithZipkinURL(ctx, urlStr)
+	}
+	return ctx
+}
+
 func EnhanceStdioContextFuncs(funcs ...server.StdioContextFunc) server.StdioContextFunc {
 	return func(ctx context.Context) context.Context {
 		for _, f := range funcs {
@@ -165,10 +183,10 @@ func WithSkyWalkingURLAndInsecure(ctx context.Context, url string) context.Conte
 
 // EnhanceStdioContextFunc returns a StdioContextFunc that composes all the provided StdioContextFuncs.
 func EnhanceStdioContextFunc() server.StdioContextFunc {
-	return EnhanceStdioContextFuncs(ExtractSWURLFromCfg)
+	return EnhanceStdioContextFuncs(ExtractSWURLFromCfg, ExtractZipkinURLFromCfg)
 }
 
 // EnhanceHTTPContextFunc returns a SSEContextFunc that composes all the provided HTTPContextFuncs.
 func EnhanceHTTPContextFunc() server.SSEContextFunc {
-	return EnhanceSSEContextFuncs(ExtractSWURLFromHeaders)
+	return EnhanceSSEContextFuncs(ExtractSWURLFromHeaders, ExtractZipkinURLFromHeaders)
 }
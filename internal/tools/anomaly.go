@@ -0,0 +1,189 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+)
+
+const (
+	// madConsistency scales a median absolute deviation into a standard deviation for normal data.
+	madConsistency = 1.4826
+	// maxAnomalyScore caps the scores, which are unbounded when the baseline is flat.
+	maxAnomalyScore = 100
+	ewmaAlpha       = 0.2
+	// ewmaWarmup is the number of points the EWMA learns from before scoring.
+	ewmaWarmup = 10
+	// minSeasonalSamples is the number of baseline points an hour of the day needs to have a profile.
+	minSeasonalSamples = 3
+)
+
+// Names of the anomaly detectors.
+const (
+	DetectorMAD      = "mad"
+	DetectorEWMA     = "ewma"
+	DetectorSeasonal = "seasonal"
+)
+
+// pointScore is the value a detector expected at a point and how far the actual value is from it,
+// in robust standard deviations, positive above the expectation. Points a detector cannot score are not ok.
+type pointScore struct {
+	expected float64
+	score    float64
+	ok       bool
+}
+
+// detectedPoint is a point of the window flagged by at least one detector, with the highest score.
+type detectedPoint struct {
+	index     int
+	expected  float64
+	score     float64
+	detectors []string
+}
+
+// anomalyScore is the distance of the value from the center in units of the scale. The scale is floored
+// relatively to the center, so that a flat baseline does not turn noise into infinite scores.
+func anomalyScore(value, center, scale float64) float64 {
+	scale = max(scale, math.Abs(center)*0.01, 1e-9)
+	score := (value - center) / scale
+	return math.Max(-maxAnomalyScore, math.Min(maxAnomalyScore, score))
+}
+
+func pointValues(points []seriesPoint) []float64 {
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.value
+	}
+	return values
+}
+
+// madScores scores the window against the median and the median absolute deviation of the baseline,
+// which are not skewed by the outliers of the baseline.
+func madScores(baseline, window []seriesPoint) []pointScore {
+	scores := make([]pointScore, len(window))
+	if len(baseline) == 0 {
+		return scores
+	}
+	center, mad := medianAbsoluteDeviation(pointValues(baseline))
+	for i, point := range window {
+		scores[i] = pointScore{expected: center, score: anomalyScore(point.value, center, madConsistency*mad), ok: true}
+	}
+	return scores
+}
+
+// ewmaScores scores each point of the window against the exponentially weighted moving average
+// and variance of the points before it, so that the expectation follows the recent trend.
+func ewmaScores(baseline, window []seriesPoint, alpha float64) []pointScore {
+	scores := make([]pointScore, len(window))
+	var mean, variance float64
+	seen := 0
+	update := func(value float64) {
+		if seen == 0 {
+			mean = value
+		} else {
+			diff := value - mean
+			increment := alpha * diff
+			mean += increment
+			variance = (1 - alpha) * (variance + diff*increment)
+		}
+		seen++
+	}
+
+	for _, point := range baseline {
+		update(point.value)
+	}
+	for i, point := range window {
+		if seen >= ewmaWarmup {
+			scores[i] = pointScore{expected: mean, score: anomalyScore(point.value, mean, math.Sqrt(variance)), ok: true}
+		}
+		update(point.value)
+	}
+	return scores
+}
+
+// seasonalScores scores the window against the profile of the baseline by hour of the day, i.e. the
+// median of each hour, with the deviation of the baseline from its profile as the scale.
+func seasonalScores(baseline, window []seriesPoint) []pointScore {
+	scores := make([]pointScore, len(window))
+	byHour := make(map[int][]float64)
+	for _, point := range baseline {
+		byHour[point.time.Hour()] = append(byHour[point.time.Hour()], point.value)
+	}
+	profile := make(map[int]float64, len(byHour))
+	for hour, values := range byHour {
+		if len(values) >= minSeasonalSamples {
+			profile[hour] = median(values)
+		}
+	}
+
+	var residuals []float64
+	for _, point := range baseline {
+		if expected, ok := profile[point.time.Hour()]; ok {
+			residuals = append(residuals, point.value-expected)
+		}
+	}
+	if len(residuals) == 0 {
+		return scores
+	}
+	_, mad := medianAbsoluteDeviation(residuals)
+
+	for i, point := range window {
+		if expected, ok := profile[point.time.Hour()]; ok {
+			scores[i] = pointScore{expected: expected, score: anomalyScore(point.value, expected, madConsistency*mad), ok: true}
+		}
+	}
+	return scores
+}
+
+// detectAnomalies runs the detectors over the window and returns the points any of them scores
+// beyond the threshold, in time order.
+func detectAnomalies(baseline, window []seriesPoint, threshold float64, detectors []string) []detectedPoint {
+	scoresByDetector := make(map[string][]pointScore, len(detectors))
+	for _, detector := range detectors {
+		switch detector {
+		case DetectorMAD:
+			scoresByDetector[detector] = madScores(baseline, window)
+		case DetectorEWMA:
+			scoresByDetector[detector] = ewmaScores(baseline, window, ewmaAlpha)
+		case DetectorSeasonal:
+			scoresByDetector[detector] = seasonalScores(baseline, window)
+		}
+	}
+
+	var detected []detectedPoint
+	for i := range window {
+		var point *detectedPoint
+		for _, detector := range detectors {
+			scores := scoresByDetector[detector]
+			if scores == nil || !scores[i].ok || math.Abs(scores[i].score) < threshold {
+				continue
+			}
+			if point == nil {
+				point = &detectedPoint{index: i}
+			}
+			point.detectors = append(point.detectors, detector)
+			if math.Abs(scores[i].score) > math.Abs(point.score) {
+				point.expected, point.score = scores[i].expected, scores[i].score
+			}
+		}
+		if point != nil {
+			detected = append(detected, *point)
+		}
+	}
+	return detected
+}
@@ -0,0 +1,342 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var anomalyStart = time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+// noise is a small deterministic deviation, cycling through -2, -1, 0, 1 and 2.
+func noise(i int) float64 {
+	return float64(i%5 - 2)
+}
+
+// series builds points from the values, starting at anomalyStart and step apart.
+func series(step time.Duration, values ...float64) []seriesPoint {
+	points := make([]seriesPoint, len(values))
+	for i, value := range values {
+		points[i] = seriesPoint{time: anomalyStart.Add(time.Duration(i) * step), value: value}
+	}
+	return points
+}
+
+// noisy returns n values around the level.
+func noisy(n int, level float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = level + noise(i)
+	}
+	return values
+}
+
+// flat returns n values equal to the level.
+func flat(n int, level float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = level
+	}
+	return values
+}
+
+// with returns a copy of the values with the one at index replaced.
+func with(values []float64, index int, value float64) []float64 {
+	values = append([]float64(nil), values...)
+	values[index] = value
+	return values
+}
+
+// businessHours returns hourly values of the given days, high from 9 to 17 o'clock and low otherwise.
+func businessHours(days int) []float64 {
+	values := make([]float64, days*24)
+	for i := range values {
+		level := 10.0
+		if hour := i % 24; hour >= 9 && hour <= 17 {
+			level = 100
+		}
+		values[i] = level + noise(i)
+	}
+	return values
+}
+
+// seasonalWindow returns the day after three days of business hours, high at 3 o'clock as during
+// the business hours and low at noon as during the night.
+func seasonalWindow() []seriesPoint {
+	window := series(time.Hour, with(with(businessHours(4)[72:], 3, 100), 12, 10)...)
+	for i := range window {
+		window[i].time = window[i].time.Add(72 * time.Hour)
+	}
+	return window
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{name: "empty", values: nil, want: 0},
+		{name: "single", values: []float64{3}, want: 3},
+		{name: "odd", values: []float64{5, 1, 3}, want: 3},
+		{name: "even", values: []float64{4, 1, 3, 2}, want: 2.5},
+		{name: "outlier", values: []float64{1, 2, 3, 1000}, want: 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.values); got != tt.want {
+				t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMedianAbsoluteDeviation(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		wantCenter float64
+		wantMAD    float64
+	}{
+		{name: "spread", values: []float64{1, 1, 2, 2, 4, 6, 9}, wantCenter: 2, wantMAD: 1},
+		{name: "outlier", values: []float64{10, 11, 9, 10, 500}, wantCenter: 10, wantMAD: 1},
+		{name: "flat", values: flat(10, 7), wantCenter: 7, wantMAD: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			center, mad := medianAbsoluteDeviation(tt.values)
+			if center != tt.wantCenter || mad != tt.wantMAD {
+				t.Errorf("medianAbsoluteDeviation(%v) = %v, %v, want %v, %v", tt.values, center, mad, tt.wantCenter, tt.wantMAD)
+			}
+		})
+	}
+}
+
+// scoreCheck is the expected score of a point of the window: at least min in absolute value when
+// anomalous, with the sign of the deviation, or below max in absolute value otherwise. The expectation
+// may be off by the noise.
+type scoreCheck struct {
+	index    int
+	expected float64
+	min      float64
+	max      float64
+}
+
+func checkScores(t *testing.T, scores []pointScore, checks []scoreCheck) {
+	t.Helper()
+	for _, check := range checks {
+		score := scores[check.index]
+		switch {
+		case !score.ok:
+			t.Errorf("point %d is not scored", check.index)
+		case math.IsNaN(score.score) || math.IsInf(score.score, 0):
+			t.Errorf("point %d has score %v", check.index, score.score)
+		case math.Abs(score.expected-check.expected) > 2:
+			t.Errorf("point %d expected %v, want %v", check.index, score.expected, check.expected)
+		case check.min > 0 && score.score < check.min, check.min < 0 && score.score > check.min:
+			t.Errorf("point %d has score %v, want beyond %v", check.index, score.score, check.min)
+		case check.max > 0 && math.Abs(score.score) > check.max:
+			t.Errorf("point %d has score %v, want within %v", check.index, score.score, check.max)
+		}
+	}
+}
+
+func TestMADScores(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline []seriesPoint
+		window   []seriesPoint
+		checks   []scoreCheck
+	}{
+		{
+			name:     "spike",
+			baseline: series(time.Minute, noisy(60, 100)...),
+			window:   series(time.Minute, with(noisy(10, 100), 5, 200)...),
+			checks:   []scoreCheck{{index: 5, expected: 100, min: 50}, {index: 4, expected: 100, max: 3}},
+		},
+		{
+			name:     "drop",
+			baseline: series(time.Minute, noisy(60, 100)...),
+			window:   series(time.Minute, with(noisy(10, 100), 5, 50)...),
+			checks:   []scoreCheck{{index: 5, expected: 100, min: -20}, {index: 6, expected: 100, max: 3}},
+		},
+		{
+			name:     "flat baseline",
+			baseline: series(time.Minute, flat(60, 100)...),
+			window:   series(time.Minute, 100, 101, 1000),
+			checks: []scoreCheck{
+				{index: 0, expected: 100, max: 0.001},
+				{index: 1, expected: 100, min: 0.5, max: 1.5},
+				{index: 2, expected: 100, min: maxAnomalyScore},
+			},
+		},
+		{
+			name:     "flat zero baseline",
+			baseline: series(time.Minute, flat(60, 0)...),
+			window:   series(time.Minute, 0, 1, -1),
+			checks: []scoreCheck{
+				{index: 0, expected: 0, max: 0.001},
+				{index: 1, expected: 0, min: maxAnomalyScore},
+				{index: 2, expected: 0, min: -maxAnomalyScore},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkScores(t, madScores(tt.baseline, tt.window), tt.checks)
+		})
+	}
+}
+
+func TestMADScoresWithoutBaseline(t *testing.T) {
+	for i, score := range madScores(nil, series(time.Minute, 1, 2, 3)) {
+		if score.ok {
+			t.Errorf("point %d is scored without baseline", i)
+		}
+	}
+}
+
+func TestEWMAScores(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline []seriesPoint
+		window   []seriesPoint
+		checks   []scoreCheck
+	}{
+		{
+			name:     "spike",
+			baseline: series(time.Minute, flat(60, 100)...),
+			window:   series(time.Minute, 100, 100, 200),
+			checks:   []scoreCheck{{index: 0, expected: 100, max: 0.001}, {index: 2, expected: 100, min: 50}},
+		},
+		{
+			name:     "drop",
+			baseline: series(time.Minute, flat(60, 100)...),
+			window:   series(time.Minute, 100, 100, 50),
+			checks:   []scoreCheck{{index: 2, expected: 100, min: -20}},
+		},
+		{
+			name:     "flat zero baseline",
+			baseline: series(time.Minute, flat(60, 0)...),
+			window:   series(time.Minute, 0, 5),
+			checks:   []scoreCheck{{index: 0, expected: 0, max: 0.001}, {index: 1, expected: 0, min: maxAnomalyScore}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkScores(t, ewmaScores(tt.baseline, tt.window, ewmaAlpha), tt.checks)
+		})
+	}
+}
+
+func TestEWMAScoresWarmup(t *testing.T) {
+	scores := ewmaScores(series(time.Minute, flat(ewmaWarmup-2, 100)...), series(time.Minute, flat(4, 100)...), ewmaAlpha)
+	got := make([]bool, len(scores))
+	for i, score := range scores {
+		got[i] = score.ok
+	}
+	if want := []bool{false, false, true, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scored points = %v, want %v", got, want)
+	}
+}
+
+func TestSeasonalScores(t *testing.T) {
+	window := seasonalWindow()
+	scores := seasonalScores(series(time.Hour, businessHours(3)...), window)
+	checkScores(t, scores, []scoreCheck{
+		{index: 3, expected: 10, min: 20},
+		{index: 4, expected: 10, max: 3},
+		{index: 11, expected: 100, max: 3},
+		{index: 12, expected: 100, min: -20},
+	})
+
+	// Two days are too few samples for a profile of each hour.
+	for i, score := range seasonalScores(series(time.Hour, businessHours(2)...), window) {
+		if score.ok {
+			t.Errorf("point %d is scored with too few samples", i)
+		}
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseline      []seriesPoint
+		window        []seriesPoint
+		detectors     []string
+		wantIndexes   []int
+		wantDetectors [][]string
+		wantPositive  []bool
+	}{
+		{
+			name:          "spike",
+			baseline:      series(time.Minute, noisy(60, 100)...),
+			window:        series(time.Minute, with(noisy(10, 100), 5, 200)...),
+			detectors:     []string{DetectorMAD, DetectorEWMA},
+			wantIndexes:   []int{5},
+			wantDetectors: [][]string{{DetectorMAD, DetectorEWMA}},
+			wantPositive:  []bool{true},
+		},
+		{
+			name:          "drop",
+			baseline:      series(time.Minute, noisy(60, 100)...),
+			window:        series(time.Minute, with(noisy(10, 100), 5, 20)...),
+			detectors:     []string{DetectorMAD, DetectorEWMA},
+			wantIndexes:   []int{5},
+			wantDetectors: [][]string{{DetectorMAD, DetectorEWMA}},
+			wantPositive:  []bool{false},
+		},
+		{
+			name:      "flat baseline",
+			baseline:  series(time.Minute, flat(60, 100)...),
+			window:    series(time.Minute, flat(10, 100)...),
+			detectors: []string{DetectorMAD, DetectorEWMA, DetectorSeasonal},
+		},
+		{
+			name:          "hour of day",
+			baseline:      series(time.Hour, businessHours(3)...),
+			window:        seasonalWindow(),
+			detectors:     []string{DetectorSeasonal},
+			wantIndexes:   []int{3, 12},
+			wantDetectors: [][]string{{DetectorSeasonal}, {DetectorSeasonal}},
+			wantPositive:  []bool{true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detected := detectAnomalies(tt.baseline, tt.window, 3, tt.detectors)
+			if len(detected) != len(tt.wantIndexes) {
+				t.Fatalf("detected %+v, want points %v", detected, tt.wantIndexes)
+			}
+			for i, point := range detected {
+				if point.index != tt.wantIndexes[i] {
+					t.Errorf("point %d has index %d, want %d", i, point.index, tt.wantIndexes[i])
+				}
+				if !reflect.DeepEqual(point.detectors, tt.wantDetectors[i]) {
+					t.Errorf("point %d is detected by %v, want %v", i, point.detectors, tt.wantDetectors[i])
+				}
+				if (point.score > 0) != tt.wantPositive[i] {
+					t.Errorf("point %d has score %v, want positive %v", i, point.score, tt.wantPositive[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// Text chart formats of time series results.
+const (
+	FormatSparkline = "sparkline"
+	FormatChart     = "chart"
+)
+
+const (
+	// maxChartWidth is the number of columns series longer than it are downsampled to.
+	maxChartWidth = 60
+	chartHeight   = 8
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// NamedSeries is a time series with one value per step from the start, nil for missing values.
+type NamedSeries struct {
+	Name   string
+	Start  string
+	Step   api.Step
+	Values []*float64
+}
+
+// renderCharts renders the series as one sparkline each, or as one chart each, returning false
+// for other formats or when there is no series.
+func renderCharts(series []NamedSeries, format string) (string, bool) {
+	if len(series) == 0 || format != FormatSparkline && format != FormatChart {
+		return "", false
+	}
+	var sb strings.Builder
+	for i, s := range series {
+		if format == FormatSparkline {
+			sb.WriteString(renderSparkline(s))
+			sb.WriteString("\n")
+			continue
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(renderChart(s))
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), true
+}
+
+// renderSparkline renders a series on one line, e.g. "service_cpm ▁▂▅█▃ min 12 max 80 last 35".
+func renderSparkline(s NamedSeries) string {
+	low, high, _, count := seriesStats(s.Values)
+	if count == 0 {
+		return s.Name + " (no data)"
+	}
+	// downsampled values are scaled on their own range, which averaging narrows
+	values := downsample(s.Values, maxChartWidth)
+	scaleLow, scaleHigh, _, _ := seriesStats(values)
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v == nil {
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(sparkBlocks[scaleLevel(*v, scaleLow, scaleHigh, len(sparkBlocks))])
+	}
+	last := ""
+	for i := len(s.Values) - 1; i >= 0; i-- {
+		if s.Values[i] != nil {
+			last = " last " + formatChartValue(*s.Values[i])
+			break
+		}
+	}
+	return fmt.Sprintf("%s %s min %s max %s%s", s.Name, sb.String(), formatChartValue(low), formatChartValue(high), last)
+}
+
+// renderChart renders a series as a small multi-line chart, with the time span and the min, max and average.
+func renderChart(s NamedSeries) string {
+	values := downsample(s.Values, maxChartWidth)
+	low, high, avg, count := seriesStats(s.Values)
+	if count == 0 {
+		return s.Name + "\n(no data)\n"
+	}
+
+	grid := make([][]rune, chartHeight)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(" ", len(values)))
+	}
+	for col, v := range values {
+		if v != nil {
+			grid[chartHeight-1-scaleLevel(*v, low, high, chartHeight)][col] = '*'
+		}
+	}
+
+	highLabel, lowLabel := formatChartValue(high), formatChartValue(low)
+	labelWidth := max(len(highLabel), len(lowLabel))
+	var sb strings.Builder
+	sb.WriteString(s.Name + "\n")
+	for row, line := range grid {
+		label, axis := "", '│'
+		switch row {
+		case 0:
+			label, axis = highLabel, '┤'
+		case chartHeight - 1:
+			label, axis = lowLabel, '┤'
+		}
+		fmt.Fprintf(&sb, "%*s %c%s\n", labelWidth, label, axis, strings.TrimRight(string(line), " "))
+	}
+	fmt.Fprintf(&sb, "%*s └%s\n", labelWidth, "", strings.Repeat("─", len(values)))
+	if span := timeSpan(s); span != "" {
+		fmt.Fprintf(&sb, "%*s  %s\n", labelWidth, "", span)
+	}
+	fmt.Fprintf(&sb, "min %s  max %s  avg %s  (%d of %d points)\n",
+		lowLabel, highLabel, formatChartValue(avg), count, len(s.Values))
+	return sb.String()
+}
+
+// downsample averages consecutive values into at most width values, missing values being skipped.
+func downsample(values []*float64, width int) []*float64 {
+	if len(values) <= width {
+		return values
+	}
+	sampled := make([]*float64, width)
+	for i := range sampled {
+		from, to := i*len(values)/width, (i+1)*len(values)/width
+		var sum float64
+		var count int
+		for _, v := range values[from:to] {
+			if v != nil {
+				sum += *v
+				count++
+			}
+		}
+		if count > 0 {
+			avg := sum / float64(count)
+			sampled[i] = &avg
+		}
+	}
+	return sampled
+}
+
+func seriesStats(values []*float64) (low, high, avg float64, count int) {
+	low, high = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for _, v := range values {
+		if v != nil {
+			low, high = min(low, *v), max(high, *v)
+			sum += *v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, 0, 0
+	}
+	return low, high, sum / float64(count), count
+}
+
+// scaleLevel maps a value between low and high to one of the levels, the middle one for flat series.
+func scaleLevel(v, low, high float64, levels int) int {
+	if high == low {
+		return levels / 2
+	}
+	level := int(math.Round((v - low) / (high - low) * float64(levels-1)))
+	return min(max(level, 0), levels-1)
+}
+
+// timeSpan returns the first and the last time of the series, e.g. "2025-06-01 1030 → 2025-06-01 1129".
+func timeSpan(s NamedSeries) string {
+	layout, ok := utils.StepFormats[s.Step]
+	if !ok || s.Start == "" {
+		return ""
+	}
+	start, err := time.Parse(layout, s.Start)
+	if err != nil {
+		return ""
+	}
+	end := start.Add(time.Duration(len(s.Values)-1) * utils.StepDuration[s.Step])
+	return s.Start + " → " + end.Format(layout)
+}
+
+func formatChartValue(v float64) string {
+	return strconv.FormatFloat(roundFloat(v, 2), 'f', -1, 64)
+}
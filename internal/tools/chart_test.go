@@ -0,0 +1,134 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// ramp returns the values from 0 to n-1.
+func ramp(n int) []*float64 {
+	vs := make([]float64, n)
+	for i := range vs {
+		vs[i] = float64(i)
+	}
+	return values(vs...)
+}
+
+func TestDownsample(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []*float64
+		width  int
+		want   []*float64
+	}{
+		{name: "narrower than the width", values: values(1, 2, 3), width: 5, want: values(1, 2, 3)},
+		{name: "pairs", values: values(1, 3, 5, 7, 9, 11), width: 3, want: values(2, 6, 10)},
+		{name: "uneven chunks", values: values(1, 2, 3, 4, 5), width: 2, want: values(1.5, 4)},
+		{name: "missing values skipped", values: values(1, nan, 5, 7), width: 2, want: values(1, 6)},
+		{name: "missing chunk", values: values(nan, nan, 5, 7), width: 2, want: values(nan, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := downsample(tt.values, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("downsample = %v, want %v", rowValues([][]*float64{got}), rowValues([][]*float64{tt.want}))
+			}
+		})
+	}
+}
+
+func TestScaleLevel(t *testing.T) {
+	tests := []struct {
+		v, low, high float64
+		want         int
+	}{
+		{v: 0, low: 0, high: 70, want: 0},
+		{v: 70, low: 0, high: 70, want: 7},
+		{v: 30, low: 0, high: 70, want: 3},
+		{v: 5, low: 5, high: 5, want: 4},
+		{v: 90, low: 0, high: 70, want: 7},
+	}
+	for _, tt := range tests {
+		if got := scaleLevel(tt.v, tt.low, tt.high, len(sparkBlocks)); got != tt.want {
+			t.Errorf("scaleLevel(%v, %v, %v) = %d, want %d", tt.v, tt.low, tt.high, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []*float64
+		want   string
+	}{
+		{name: "ramp", values: values(0, 10, 20, 30, 40, 50, 60, 70), want: "cpm ▁▂▃▄▅▆▇█ min 0 max 70 last 70"},
+		{name: "flat", values: values(5, 5, 5), want: "cpm ▅▅▅ min 5 max 5 last 5"},
+		{name: "missing values", values: values(1, nan, 3.456, nan), want: "cpm ▁ █  min 1 max 3.46 last 3.46"},
+		{name: "no data", values: values(nan, nan), want: "cpm (no data)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderSparkline(NamedSeries{Name: "cpm", Values: tt.values}); got != tt.want {
+				t.Errorf("renderSparkline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSparklineDownsampled(t *testing.T) {
+	got := renderSparkline(NamedSeries{Name: "cpm", Values: ramp(3 * maxChartWidth)})
+	line, stats, _ := strings.Cut(strings.TrimPrefix(got, "cpm "), " min")
+	if n := utf8.RuneCountInString(line); n != maxChartWidth {
+		t.Errorf("sparkline of %d columns, want %d", n, maxChartWidth)
+	}
+	if !strings.HasPrefix(line, "▁") || !strings.HasSuffix(line, "█") {
+		t.Errorf("downsampled sparkline %q does not span the whole scale", line)
+	}
+	// the min and max are those of the original values, not of the averages
+	if want := " 0 max 179 last 179"; stats != want {
+		t.Errorf("stats = %q, want %q", stats, want)
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	chart := renderChart(NamedSeries{Name: "cpm", Start: "2025-06-01 1030", Step: api.StepMinute, Values: ramp(2 * maxChartWidth)})
+	lines := strings.Split(strings.TrimSuffix(chart, "\n"), "\n")
+	if want := chartHeight + 4; len(lines) != want {
+		t.Fatalf("chart of %d lines, want %d:\n%s", len(lines), want, chart)
+	}
+	if !strings.HasPrefix(lines[1], "119 ┤") || !strings.HasSuffix(lines[1], "*") {
+		t.Errorf("top line = %q, want the max label and the last point", lines[1])
+	}
+	if !strings.HasPrefix(lines[chartHeight], "  0 ┤*") {
+		t.Errorf("bottom line = %q, want the min label and the first point", lines[chartHeight])
+	}
+	if want := "2025-06-01 1030 → 2025-06-01 1229"; !strings.Contains(lines[chartHeight+2], want) {
+		t.Errorf("time span line = %q, want %q", lines[chartHeight+2], want)
+	}
+	if want := "min 0  max 119  avg 59.5  (120 of 120 points)"; lines[chartHeight+3] != want {
+		t.Errorf("summary line = %q, want %q", lines[chartHeight+3], want)
+	}
+}
@@ -0,0 +1,246 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/contextkey"
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// defaultTimeRange is the window queried when a request carries no start time.
+const defaultTimeRange = 30 * time.Minute
+
+// Windows up to these lengths are queried with the MINUTE and HOUR steps, longer ones with DAY.
+const (
+	maxMinuteStepWindow = 6 * time.Hour
+	maxHourStepWindow   = 7 * 24 * time.Hour
+)
+
+// absoluteLayouts are the accepted layouts of absolute times besides RFC 3339,
+// interpreted in the timezone of OAP. The last ones are the OAP step formats.
+var absoluteLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	utils.StepFormats[api.StepSecond],
+	utils.StepFormats[api.StepMinute],
+	utils.StepFormats[api.StepHour],
+	utils.StepFormats[api.StepDay],
+}
+
+// relativeTime matches times relative to now, e.g. "now", "last 30m", "-2h", "now-1d" or "3 days".
+var relativeTime = regexp.MustCompile(`^(?:now)?\s*(?:(?:last|-)\s*)?(?:(\d+)\s*([a-z]+))?$`)
+
+var relativeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour,
+}
+
+// oapClock is the clock of an OAP, whose timezone and current time may differ from the local ones.
+// The local clock stands in for an OAP that cannot tell its time. The clock is queried again after expiresAt.
+type oapClock struct {
+	location  *time.Location
+	skew      time.Duration
+	expiresAt time.Time
+}
+
+func (c *oapClock) now() time.Time {
+	return time.Now().Add(c.skew).In(c.location)
+}
+
+// clockTTL is how long the clock of an OAP is cached, so that a drifting clock or a changed timezone
+// is picked up, and clockRetryInterval how long the local clock stands in for an OAP that cannot tell its time.
+const (
+	clockTTL           = 10 * time.Minute
+	clockRetryInterval = time.Minute
+)
+
+// oapClocks caches the clock of each OAP, keyed by the OAP URL.
+var oapClocks sync.Map
+
+// clockOf returns the clock of the OAP of the context, querying its time info every clockTTL. The local
+// clock is used when OAP cannot tell its time, and the query retried after clockRetryInterval.
+func clockOf(ctx context.Context) *oapClock {
+	baseURL, _ := ctx.Value(contextkey.BaseURL{}).(string)
+	if cached, ok := oapClocks.Load(baseURL); ok {
+		if clock := cached.(*oapClock); time.Now().Before(clock.expiresAt) {
+			return clock
+		}
+	}
+
+	info, err := metadata.ServerTimeInfo(ctx)
+	if err != nil {
+		clock := &oapClock{location: time.Local, expiresAt: time.Now().Add(clockRetryInterval)}
+		oapClocks.Store(baseURL, clock)
+		return clock
+	}
+	clock := &oapClock{location: time.Local, expiresAt: time.Now().Add(clockTTL)}
+	if info.Timezone != nil {
+		if zone, err := time.Parse("-0700", *info.Timezone); err == nil {
+			_, offset := zone.Zone()
+			clock.location = time.FixedZone(*info.Timezone, offset)
+		}
+	}
+	if info.CurrentTimestamp != nil {
+		clock.skew = time.Until(time.UnixMilli(*info.CurrentTimestamp))
+	}
+	oapClocks.Store(baseURL, clock)
+	return clock
+}
+
+// TimeRange is embedded in the requests of tools that query OAP over a time window.
+type TimeRange struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	Step  string `json:"step,omitempty"`
+}
+
+// toDuration converts the time range into an OAP duration, defaulting to the last 30 minutes.
+func (t TimeRange) toDuration(ctx context.Context) (api.Duration, error) {
+	start, end, step, err := t.bounds(ctx)
+	if err != nil {
+		return api.Duration{}, err
+	}
+	layout := utils.StepFormats[step]
+	return api.Duration{
+		Start: start.Format(layout),
+		End:   end.Format(layout),
+		Step:  step,
+	}, nil
+}
+
+// bounds parses the start and the end of the time range in the timezone of OAP,
+// choosing the step from the length of the window when it is not given.
+func (t TimeRange) bounds(ctx context.Context) (start, end time.Time, step api.Step, err error) {
+	clock := clockOf(ctx)
+	now := clock.now()
+
+	end = now
+	if t.End != "" {
+		if end, err = parseTime(t.End, now, clock.location); err != nil {
+			return start, end, step, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	start = end.Add(-defaultTimeRange)
+	if t.Start != "" {
+		// relative starts go back from the end, e.g. "last 1h" before a given end
+		if start, err = parseTime(t.Start, end, clock.location); err != nil {
+			return start, end, step, fmt.Errorf("invalid start: %w", err)
+		}
+	}
+	if start.After(end) {
+		return start, end, step, fmt.Errorf("start %q is after end %q", t.Start, t.End)
+	}
+
+	step = stepFor(end.Sub(start))
+	if t.Step != "" {
+		step = api.Step(strings.ToUpper(t.Step))
+		if !step.IsValid() {
+			return start, end, step, fmt.Errorf("invalid step %q", t.Step)
+		}
+	}
+	return start, end, step, nil
+}
+
+// stepFor chooses the step of a window from its length.
+func stepFor(window time.Duration) api.Step {
+	switch {
+	case window <= maxMinuteStepWindow:
+		return api.StepMinute
+	case window <= maxHourStepWindow:
+		return api.StepHour
+	default:
+		return api.StepDay
+	}
+}
+
+// parseTime parses a time relative to the reference, an RFC 3339 time, or an absolute time in the location.
+func parseTime(value string, reference time.Time, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "now") {
+		return reference, nil
+	}
+	if offset, ok, err := parseRelative(value); ok || err != nil {
+		return reference.Add(-offset), err
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.In(location), nil
+	}
+	for _, layout := range absoluteLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a relative time such as 'last 30m' nor a time such as '2025-06-01T10:30:00Z'", value)
+}
+
+// parseRelative parses how far back a relative time such as "last 30m", "-2h" or "now-1d" goes,
+// returning false when the value is not relative.
+func parseRelative(value string) (time.Duration, bool, error) {
+	value = strings.TrimSpace(value)
+	match := relativeTime.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		return 0, false, nil
+	}
+	if match[1] == "" {
+		return 0, true, fmt.Errorf("missing amount in %q", value)
+	}
+	unit, ok := relativeUnits[match[2]]
+	if !ok && len(match[2]) > 2 {
+		unit, ok = relativeUnits[strings.TrimSuffix(match[2], "s")]
+	}
+	if !ok {
+		return 0, true, fmt.Errorf("unknown unit %q in %q", match[2], value)
+	}
+	amount, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid amount in %q: %w", value, err)
+	}
+	return time.Duration(amount) * unit, true, nil
+}
+
+// WithTimeRange adds the arguments bound to TimeRange to a tool.
+func WithTimeRange() mcp.ToolOption {
+	return combineOptions(
+		mcp.WithString("start",
+			mcp.Description("Start of the time window, either relative to the end such as 'last 30m', '2h' or '7d', "+
+				"or a time such as '2025-06-01T10:30:00Z' or '2025-06-01 10:30' in the timezone of OAP. Defaults to 30 minutes before end")),
+		mcp.WithString("end",
+			mcp.Description("End of the time window, either relative to now such as 'now' or 'now-1h', or a time like start. "+
+				"Defaults to now as seen by OAP")),
+		mcp.WithString("step", mcp.Enum(string(api.StepSecond), string(api.StepMinute), string(api.StepHour), string(api.StepDay)),
+			mcp.Description("Time bucket granularity of the window. Chosen from the length of the window when omitted: "+
+				"MINUTE up to 6 hours, HOUR up to 7 days, DAY beyond")),
+	)
+}
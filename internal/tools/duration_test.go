@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"testing"
+	"time"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+func TestParseRelative(t *testing.T) {
+	tests := []struct {
+		value        string
+		want         time.Duration
+		wantRelative bool
+		wantErr      bool
+	}{
+		{value: "last 30m", want: 30 * time.Minute, wantRelative: true},
+		{value: "-2h", want: 2 * time.Hour, wantRelative: true},
+		{value: "now-1d", want: 24 * time.Hour, wantRelative: true},
+		{value: "now - 1w", want: 7 * 24 * time.Hour, wantRelative: true},
+		{value: "3 days", want: 3 * 24 * time.Hour, wantRelative: true},
+		{value: "Last 10 Mins", want: 10 * time.Minute, wantRelative: true},
+		{value: " 45s ", want: 45 * time.Second, wantRelative: true},
+		{value: "last", wantRelative: true, wantErr: true},
+		{value: "5 ms", wantRelative: true, wantErr: true},
+		{value: "2 fortnights", wantRelative: true, wantErr: true},
+		{value: "2025-06-01"},
+		{value: "10:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, relative, err := parseRelative(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if relative != tt.wantRelative || got != tt.want {
+				t.Errorf("parseRelative = %v, %v, want %v, %v", got, relative, tt.want, tt.wantRelative)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	location := time.FixedZone("+0800", 8*60*60)
+	reference := time.Date(2026, 1, 5, 12, 0, 0, 0, location)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "now", want: reference},
+		{value: "NOW", want: reference},
+		{value: "last 30m", want: reference.Add(-30 * time.Minute)},
+		{value: "now-1d", want: reference.Add(-24 * time.Hour)},
+		{value: "2026-01-05T02:30:00Z", want: time.Date(2026, 1, 5, 10, 30, 0, 0, location)},
+		{value: "2026-01-05T10:30:00+08:00", want: time.Date(2026, 1, 5, 10, 30, 0, 0, location)},
+		{value: "2026-01-05T10:30:15", want: time.Date(2026, 1, 5, 10, 30, 15, 0, location)},
+		{value: "2026-01-05 10:30", want: time.Date(2026, 1, 5, 10, 30, 0, 0, location)},
+		{value: "2026-01-05 1030", want: time.Date(2026, 1, 5, 10, 30, 0, 0, location)},
+		{value: "2026-01-05 10", want: time.Date(2026, 1, 5, 10, 0, 0, 0, location)},
+		{value: "2026-01-05", want: time.Date(2026, 1, 5, 0, 0, 0, 0, location)},
+		{value: "yesterday", wantErr: true},
+		{value: "5 ms", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTime(tt.value, reference, location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTime = %v, want %v", got, tt.want)
+			}
+			if got.Location() != location {
+				t.Errorf("location = %v, want %v", got.Location(), location)
+			}
+		})
+	}
+}
+
+func TestStepFor(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   api.Step
+	}{
+		{30 * time.Minute, api.StepMinute},
+		{maxMinuteStepWindow, api.StepMinute},
+		{maxMinuteStepWindow + time.Minute, api.StepHour},
+		{maxHourStepWindow, api.StepHour},
+		{maxHourStepWindow + time.Hour, api.StepDay},
+	}
+	for _, tt := range tests {
+		if got := stepFor(tt.window); got != tt.want {
+			t.Errorf("stepFor(%v) = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultEndpointLimit = 20
+	maxEndpointLimit     = 100
+	// rankPoolSize is the minimum number of matches fetched to rank, so that the top endpoints are picked
+	// among more than the limit. The pool grows to the limit when it is larger, so a ranked search costs
+	// between rankPoolSize and maxEndpointLimit metrics queries.
+	rankPoolSize    = 50
+	rankConcurrency = 5
+)
+
+// rankExpressions are the MQE expressions used to rank the endpoints.
+var rankExpressions = map[string]string{
+	"calls":   "avg(endpoint_cpm)",
+	"latency": "avg(endpoint_resp_time)",
+}
+
+type SearchEndpointsRequest struct {
+	TimeRange
+	ServiceSelector
+	Keyword string `json:"keyword"`
+	Limit   int    `json:"limit"`
+	RankBy  string `json:"rank_by"`
+}
+
+// EndpointSearch lists the endpoints of a service matching a keyword. When ranked, only the first
+// RankedPool matches returned by OAP are ranked, not all the endpoints of the service.
+type EndpointSearch struct {
+	ServiceID   string          `json:"service_id"`
+	ServiceName string          `json:"service_name"`
+	RankBy      string          `json:"rank_by,omitempty"`
+	RankedPool  int             `json:"ranked_pool,omitempty"`
+	FailedRanks int             `json:"failed_ranks,omitempty"`
+	Endpoints   []EndpointMatch `json:"endpoints"`
+}
+
+// EndpointMatch is an endpoint with the value of the metric it is ranked by.
+type EndpointMatch struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+func searchEndpoints(ctx context.Context, req SearchEndpointsRequest) (*EndpointSearch, error) {
+	expression, rank := rankExpressions[req.RankBy]
+	if req.RankBy != "" && !rank {
+		return nil, fmt.Errorf("invalid rank_by %q, expected calls or latency", req.RankBy)
+	}
+	serviceID, serviceName, err := resolveService(ctx, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := req.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit := min(req.Limit, maxEndpointLimit)
+	if limit <= 0 {
+		limit = defaultEndpointLimit
+	}
+	pool := limit
+	if rank {
+		pool = max(limit, rankPoolSize)
+	}
+
+	endpoints, err := metadata.SearchEndpoints(ctx, serviceID, req.Keyword, pool, &duration)
+	if err != nil {
+		return nil, fmt.Errorf("search endpoints of service %v failed: %w", serviceName, err)
+	}
+
+	result := &EndpointSearch{ServiceID: serviceID, ServiceName: serviceName, RankBy: req.RankBy}
+	result.Endpoints = make([]EndpointMatch, len(endpoints))
+	for i, endpoint := range endpoints {
+		result.Endpoints[i] = EndpointMatch{ID: endpoint.ID, Name: endpoint.Name}
+	}
+	if rank {
+		_, normal, _ := parseServiceID(serviceID)
+		failed, err := rankEndpoints(ctx, result.Endpoints, expression, serviceName, normal, duration)
+		if failed > 0 && failed == len(result.Endpoints) {
+			return nil, fmt.Errorf("rank endpoints of service %v by %v failed: %w", serviceName, req.RankBy, err)
+		}
+		result.RankedPool, result.FailedRanks = len(result.Endpoints), failed
+	}
+	result.Endpoints = result.Endpoints[:min(limit, len(result.Endpoints))]
+	return result, nil
+}
+
+// rankEndpoints sorts the endpoints by the value of the expression in descending order,
+// endpoints without value coming last. It returns the number of endpoints whose query failed
+// and the first of their errors.
+func rankEndpoints(
+	ctx context.Context, endpoints []EndpointMatch, expression, serviceName string, normal bool, duration api.Duration,
+) (int, error) {
+	scope := api.ScopeEndpoint
+	errs := make([]error, len(endpoints))
+	runConcurrently(len(endpoints), rankConcurrency, func(i int) {
+		entity := &api.Entity{
+			Scope:        &scope,
+			ServiceName:  &serviceName,
+			Normal:       &normal,
+			EndpointName: &endpoints[i].Name,
+		}
+		value, ok, err := singleMQEValue(ctx, expression, entity, duration)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if ok {
+			endpoints[i].Value = &value
+		}
+	})
+
+	var failed int
+	var firstErr error
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Value == nil || endpoints[j].Value == nil {
+			return endpoints[i].Value != nil
+		}
+		return *endpoints[i].Value > *endpoints[j].Value
+	})
+	return failed, firstErr
+}
+
+var SearchEndpointsTool = NewTool[SearchEndpointsRequest, *EndpointSearch](
+	"search_endpoints",
+	"Search the endpoints of a service by keyword, optionally ranked by call volume or latency in the time window. "+
+		"The ranking only covers the first matches OAP returns, at least 50 and at most 100 as told by ranked_pool, "+
+		"so on services with more matching endpoints narrow the keyword for the busiest or slowest ones to come first",
+	searchEndpoints,
+	mcp.WithTitleAnnotation("Search endpoints"),
+	mcp.WithString("service_id", mcp.Description("The ID of the service")),
+	mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+	mcp.WithString("keyword", mcp.Description("Keyword the endpoint names contain, all endpoints when omitted")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of endpoints, defaults to 20, at most 100")),
+	mcp.WithString("rank_by", mcp.Enum("calls", "latency"),
+		mcp.Description("Rank the endpoints by calls per minute or average latency in milliseconds, in descending order")),
+	WithTimeRange(),
+)
@@ -0,0 +1,251 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// buildServiceID encodes a service name into the ID format used by OAP.
+func buildServiceID(name string, normal bool) string {
+	flag := "0"
+	if normal {
+		flag = "1"
+	}
+	return base64.StdEncoding.EncodeToString([]byte(name)) + "." + flag
+}
+
+// buildInstanceID encodes an instance name of the given service into the ID format used by OAP.
+func buildInstanceID(serviceID, name string) string {
+	return serviceID + "_" + base64.StdEncoding.EncodeToString([]byte(name))
+}
+
+// buildEndpointID encodes an endpoint name of the given service into the ID format used by OAP.
+func buildEndpointID(serviceID, name string) string {
+	return serviceID + "_" + base64.StdEncoding.EncodeToString([]byte(name))
+}
+
+// resolveServiceID returns the service ID, looking it up by name when no ID is given.
+func resolveServiceID(ctx context.Context, id, name string) (string, error) {
+	if id != "" || name == "" {
+		return id, nil
+	}
+	service, err := metadata.SearchService(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("resolve service %v failed: %w", name, err)
+	}
+	return service.ID, nil
+}
+
+// parseServiceID decodes the service name and the normal flag from a service ID.
+func parseServiceID(id string) (name string, normal bool, err error) {
+	encoded, flag, ok := strings.Cut(id, ".")
+	if !ok {
+		return "", false, fmt.Errorf("invalid service ID %q", id)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid service ID %q: %w", id, err)
+	}
+	return string(decoded), flag == "1", nil
+}
+
+// resolveService returns both the ID and the name of a service given either of them.
+func resolveService(ctx context.Context, id, name string) (serviceID, serviceName string, err error) {
+	switch {
+	case id != "":
+		if name, _, err = parseServiceID(id); err != nil {
+			return "", "", err
+		}
+		return id, name, nil
+	case name != "":
+		if id, err = resolveServiceID(ctx, "", name); err != nil {
+			return "", "", err
+		}
+		return id, name, nil
+	default:
+		return "", "", fmt.Errorf("either service_id or service_name is required")
+	}
+}
+
+// MetricsEntity is embedded in the requests of tools that query the metrics of an entity,
+// i.e. a service, an instance, an endpoint, a process, or a relation between two of them.
+type MetricsEntity struct {
+	Scope string `json:"scope,omitempty"`
+	ServiceSelector
+	InstanceName     string `json:"instance_name,omitempty"`
+	EndpointName     string `json:"endpoint_name,omitempty"`
+	ProcessName      string `json:"process_name,omitempty"`
+	DestServiceName  string `json:"dest_service_name,omitempty"`
+	DestInstanceName string `json:"dest_instance_name,omitempty"`
+	DestEndpointName string `json:"dest_endpoint_name,omitempty"`
+	DestProcessName  string `json:"dest_process_name,omitempty"`
+
+	destServiceID string
+}
+
+// scopeRequirements lists the names each scope requires besides the service.
+var scopeRequirements = map[api.Scope][]string{
+	api.ScopeServiceInstance:         {"instance_name"},
+	api.ScopeEndpoint:                {"endpoint_name"},
+	api.ScopeProcess:                 {"instance_name", "process_name"},
+	api.ScopeServiceRelation:         {"dest_service_name"},
+	api.ScopeServiceInstanceRelation: {"instance_name", "dest_service_name", "dest_instance_name"},
+	api.ScopeEndpointRelation:        {"endpoint_name", "dest_service_name", "dest_endpoint_name"},
+	api.ScopeProcessRelation:         {"instance_name", "process_name", "dest_process_name"},
+}
+
+// resolveArguments resolves the names of the entity to the exact ones.
+func (e *MetricsEntity) resolveArguments(ctx context.Context) error {
+	if err := e.ServiceSelector.resolveArguments(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	if e.ServiceID != "" {
+		if e.InstanceName != "" {
+			if e.InstanceName, err = resolveInstanceName(ctx, e.ServiceID, e.InstanceName); err != nil {
+				return err
+			}
+		}
+		if e.EndpointName != "" {
+			if e.EndpointName, err = resolveEndpointName(ctx, e.ServiceID, e.EndpointName); err != nil {
+				return err
+			}
+		}
+	}
+	if e.DestServiceName == "" {
+		return nil
+	}
+	dest, err := resolveServiceName(ctx, e.DestServiceName)
+	if err != nil {
+		return err
+	}
+	e.destServiceID, e.DestServiceName = dest.ID, dest.Name
+	if e.DestInstanceName != "" {
+		if e.DestInstanceName, err = resolveInstanceName(ctx, e.destServiceID, e.DestInstanceName); err != nil {
+			return err
+		}
+	}
+	if e.DestEndpointName != "" {
+		if e.DestEndpointName, err = resolveEndpointName(ctx, e.destServiceID, e.DestEndpointName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scope returns the scope of the entity, Service by default.
+func (e *MetricsEntity) scope() (api.Scope, error) {
+	if e.Scope == "" {
+		return api.ScopeService, nil
+	}
+	for _, scope := range api.AllScope {
+		if strings.EqualFold(string(scope), e.Scope) {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("invalid scope %q", e.Scope)
+}
+
+// toEntity converts the arguments into an OAP entity. Only the scope is set when no service is given,
+// e.g. for the expressions ranking all the services.
+func (e *MetricsEntity) toEntity() (*api.Entity, error) {
+	scope, err := e.scope()
+	if err != nil {
+		return nil, err
+	}
+	entity := &api.Entity{Scope: &scope}
+
+	serviceName := e.ServiceName
+	if e.ServiceID != "" {
+		var normal bool
+		if serviceName, normal, err = parseServiceID(e.ServiceID); err != nil {
+			return nil, err
+		}
+		entity.Normal = &normal
+	}
+	if serviceName == "" {
+		if len(scopeRequirements[scope]) > 0 {
+			return nil, fmt.Errorf("scope %v requires the service", scope)
+		}
+		return entity, nil
+	}
+	entity.ServiceName = &serviceName
+
+	names := map[string]string{
+		"instance_name":      e.InstanceName,
+		"endpoint_name":      e.EndpointName,
+		"process_name":       e.ProcessName,
+		"dest_service_name":  e.DestServiceName,
+		"dest_instance_name": e.DestInstanceName,
+		"dest_endpoint_name": e.DestEndpointName,
+		"dest_process_name":  e.DestProcessName,
+	}
+	for _, required := range scopeRequirements[scope] {
+		if names[required] == "" {
+			return nil, fmt.Errorf("scope %v requires %v", scope, required)
+		}
+	}
+
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	entity.ServiceInstanceName = optional(e.InstanceName)
+	entity.EndpointName = optional(e.EndpointName)
+	entity.ProcessName = optional(e.ProcessName)
+	entity.DestServiceName = optional(e.DestServiceName)
+	entity.DestServiceInstanceName = optional(e.DestInstanceName)
+	entity.DestEndpointName = optional(e.DestEndpointName)
+	entity.DestProcessName = optional(e.DestProcessName)
+	if e.destServiceID != "" {
+		_, destNormal, _ := parseServiceID(e.destServiceID)
+		entity.DestNormal = &destNormal
+	}
+	return entity, nil
+}
+
+// WithMetricsEntity adds the arguments bound to MetricsEntity to a tool.
+func WithMetricsEntity() mcp.ToolOption {
+	scopes := make([]string, 0, len(api.AllScope))
+	for _, scope := range api.AllScope {
+		scopes = append(scopes, string(scope))
+	}
+	return combineOptions(
+		mcp.WithString("scope", mcp.Enum(scopes...), mcp.Description("The scope of the entity, defaults to Service")),
+		mcp.WithString("service_id", mcp.Description("The ID of the service")),
+		mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+		mcp.WithString("instance_name", mcp.Description("The name of the service instance, for the ServiceInstance, Process and their relation scopes")),
+		mcp.WithString("endpoint_name", mcp.Description("The name of the endpoint, for the Endpoint and EndpointRelation scopes")),
+		mcp.WithString("process_name", mcp.Description("The name of the process, for the Process and ProcessRelation scopes")),
+		mcp.WithString("dest_service_name", mcp.Description("The name of the destination service, for the relation scopes")),
+		mcp.WithString("dest_instance_name", mcp.Description("The name of the destination instance, for the ServiceInstanceRelation scope")),
+		mcp.WithString("dest_endpoint_name", mcp.Description("The name of the destination endpoint, for the EndpointRelation scope")),
+		mcp.WithString("dest_process_name", mcp.Description("The name of the destination process, for the ProcessRelation scope")),
+	)
+}
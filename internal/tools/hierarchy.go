@@ -0,0 +1,293 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/hierarchy"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+type ServiceHierarchyRequest struct {
+	ServiceSelector
+	Layer string `json:"layer"`
+}
+
+type InstanceHierarchyRequest struct {
+	ServiceSelector
+	InstanceID   string `json:"instance_id"`
+	InstanceName string `json:"instance_name"`
+	Layer        string `json:"layer"`
+}
+
+// HierarchyGraph is the hierarchy around an entity, the edges going from the upper layer to the lower one,
+// e.g. from the GENERAL service observed by the agents to the K8S_SERVICE it runs as.
+type HierarchyGraph struct {
+	Root  string          `json:"root"`
+	Nodes []HierarchyNode `json:"nodes"`
+	Edges []HierarchyEdge `json:"edges"`
+}
+
+// HierarchyNode is a service, or an instance with its service, in a layer.
+// The same entity appears once per layer it belongs to.
+type HierarchyNode struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Layer       string `json:"layer"`
+	Level       *int   `json:"level,omitempty"`
+	Normal      bool   `json:"normal"`
+	ServiceID   string `json:"service_id,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// HierarchyEdge links the nodes by their ID and layer.
+type HierarchyEdge struct {
+	Upper      string `json:"upper"`
+	UpperLayer string `json:"upper_layer"`
+	Lower      string `json:"lower"`
+	LowerLayer string `json:"lower_layer"`
+}
+
+// resolveArguments resolves the service and the instance names to the exact ones.
+func (r *InstanceHierarchyRequest) resolveArguments(ctx context.Context) error {
+	if err := r.ServiceSelector.resolveArguments(ctx); err != nil {
+		return err
+	}
+	if r.ServiceID == "" || r.InstanceID != "" || r.InstanceName == "" {
+		return nil
+	}
+	var err error
+	r.InstanceName, err = resolveInstanceName(ctx, r.ServiceID, r.InstanceName)
+	return err
+}
+
+type hierarchyBuilder struct {
+	graph  *HierarchyGraph
+	levels map[string]int
+	nodes  map[string]bool
+	edges  map[HierarchyEdge]bool
+}
+
+func newHierarchyBuilder(ctx context.Context, root string) (*hierarchyBuilder, error) {
+	levels, err := cached(ctx, "layer_levels", func() ([]api.LayerLevel, error) {
+		return hierarchy.ListLayerLevels(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list layer levels failed: %w", err)
+	}
+	builder := &hierarchyBuilder{
+		graph:  &HierarchyGraph{Root: root, Nodes: []HierarchyNode{}, Edges: []HierarchyEdge{}},
+		levels: make(map[string]int, len(levels)),
+		nodes:  make(map[string]bool),
+		edges:  make(map[HierarchyEdge]bool),
+	}
+	for _, level := range levels {
+		builder.levels[level.Layer] = level.Level
+	}
+	return builder, nil
+}
+
+func (b *hierarchyBuilder) addNode(node HierarchyNode) {
+	key := node.ID + "|" + node.Layer
+	if b.nodes[key] {
+		return
+	}
+	b.nodes[key] = true
+	if level, ok := b.levels[node.Layer]; ok {
+		node.Level = &level
+	}
+	b.graph.Nodes = append(b.graph.Nodes, node)
+}
+
+func (b *hierarchyBuilder) addEdge(upper, lower HierarchyNode) {
+	b.addNode(upper)
+	b.addNode(lower)
+	edge := HierarchyEdge{Upper: upper.ID, UpperLayer: upper.Layer, Lower: lower.ID, LowerLayer: lower.Layer}
+	if !b.edges[edge] {
+		b.edges[edge] = true
+		b.graph.Edges = append(b.graph.Edges, edge)
+	}
+}
+
+// build sorts the nodes from the upper layers to the lower ones.
+func (b *hierarchyBuilder) build() *HierarchyGraph {
+	level := func(node HierarchyNode) int {
+		if node.Level == nil {
+			return -1
+		}
+		return *node.Level
+	}
+	sort.SliceStable(b.graph.Nodes, func(i, j int) bool {
+		ni, nj := b.graph.Nodes[i], b.graph.Nodes[j]
+		if level(ni) != level(nj) {
+			return level(ni) > level(nj)
+		}
+		if ni.Layer != nj.Layer {
+			return ni.Layer < nj.Layer
+		}
+		return ni.Name < nj.Name
+	})
+	return b.graph
+}
+
+// hierarchyLayers returns the given layer, or all the layers of the service when omitted.
+func hierarchyLayers(ctx context.Context, serviceID, layer string) ([]string, error) {
+	if layer != "" {
+		return []string{strings.ToUpper(layer)}, nil
+	}
+	services, err := cachedServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load services failed: %w", err)
+	}
+	for _, service := range services {
+		if service.ID == serviceID && len(service.Layers) > 0 {
+			return service.Layers, nil
+		}
+	}
+	return nil, fmt.Errorf("layers of service %v are unknown, layer is required", serviceID)
+}
+
+func getServiceHierarchy(ctx context.Context, req ServiceHierarchyRequest) (*HierarchyGraph, error) {
+	serviceID, serviceName, err := resolveService(ctx, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := hierarchyLayers(ctx, serviceID, req.Layer)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := newHierarchyBuilder(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceNode := func(service *api.HierarchyRelatedService) HierarchyNode {
+		return HierarchyNode{ID: service.ID, Name: service.Name, Layer: service.Layer, Normal: service.Normal}
+	}
+	for _, layer := range layers {
+		result, err := hierarchy.ServiceHierarchy(ctx, serviceID, layer)
+		if err != nil {
+			return nil, fmt.Errorf("query hierarchy of service %v in layer %v failed: %w", serviceName, layer, err)
+		}
+		for _, relation := range result.Relations {
+			if relation != nil && relation.UpperService != nil && relation.LowerService != nil {
+				builder.addEdge(serviceNode(relation.UpperService), serviceNode(relation.LowerService))
+			}
+		}
+	}
+	return builder.build(), nil
+}
+
+func getInstanceHierarchy(ctx context.Context, req InstanceHierarchyRequest) (*HierarchyGraph, error) {
+	instanceID := req.InstanceID
+	serviceID := req.ServiceID
+	if instanceID == "" {
+		if req.InstanceName == "" {
+			return nil, fmt.Errorf("either instance_id or instance_name is required")
+		}
+		var err error
+		if serviceID, _, err = resolveService(ctx, req.ServiceID, req.ServiceName); err != nil {
+			return nil, err
+		}
+		instanceID = buildInstanceID(serviceID, req.InstanceName)
+	} else if serviceID == "" {
+		serviceID, _, _ = strings.Cut(instanceID, "_")
+	}
+
+	layers, err := hierarchyLayers(ctx, serviceID, req.Layer)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := newHierarchyBuilder(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceNode := func(instance *api.HierarchyRelatedInstance) HierarchyNode {
+		return HierarchyNode{
+			ID:          instance.ID,
+			Name:        instance.Name,
+			Layer:       instance.Layer,
+			Normal:      instance.Normal,
+			ServiceID:   instance.ServiceID,
+			ServiceName: instance.ServiceName,
+		}
+	}
+	for _, layer := range layers {
+		result, err := hierarchy.InstanceHierarchy(ctx, instanceID, layer)
+		if err != nil {
+			return nil, fmt.Errorf("query hierarchy of instance %v in layer %v failed: %w", instanceID, layer, err)
+		}
+		for _, relation := range result.Relations {
+			if relation != nil && relation.UpperInstance != nil && relation.LowerInstance != nil {
+				builder.addEdge(instanceNode(relation.UpperInstance), instanceNode(relation.LowerInstance))
+			}
+		}
+	}
+	return builder.build(), nil
+}
+
+func listLayerLevels(ctx context.Context, _ struct{}) ([]api.LayerLevel, error) {
+	levels, err := hierarchy.ListLayerLevels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list layer levels failed: %w", err)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Level != levels[j].Level {
+			return levels[i].Level > levels[j].Level
+		}
+		return levels[i].Layer < levels[j].Layer
+	})
+	return levels, nil
+}
+
+var ServiceHierarchyTool = NewTool[ServiceHierarchyRequest, *HierarchyGraph](
+	"get_service_hierarchy",
+	"Get the hierarchy of a service across layers as a graph, e.g. to jump from a K8S_SERVICE to the GENERAL service "+
+		"observed by the agents, or to the MESH service and back. Edges go from the upper layer to the lower one",
+	getServiceHierarchy,
+	mcp.WithTitleAnnotation("Get service hierarchy"),
+	mcp.WithString("service_id", mcp.Description("The ID of the service")),
+	mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+	mcp.WithString("layer", mcp.Description("The layer of the service, all the layers of the service when omitted")),
+)
+
+var InstanceHierarchyTool = NewTool[InstanceHierarchyRequest, *HierarchyGraph](
+	"get_instance_hierarchy",
+	"Get the hierarchy of a service instance across layers as a graph, e.g. to jump from an agent instance to its "+
+		"Kubernetes pod and back. Edges go from the upper layer to the lower one",
+	getInstanceHierarchy,
+	mcp.WithTitleAnnotation("Get instance hierarchy"),
+	mcp.WithString("instance_id", mcp.Description("The ID of the instance")),
+	mcp.WithString("instance_name", mcp.Description("The name of the instance, partial names are resolved, requires the service")),
+	mcp.WithString("service_id", mcp.Description("The ID of the service")),
+	mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+	mcp.WithString("layer", mcp.Description("The layer of the instance, all the layers of its service when omitted")),
+)
+
+var ListLayerLevelsTool = NewTool[struct{}, []api.LayerLevel](
+	"list_layer_levels",
+	"List the hierarchy level of each layer, the higher the level the upper the layer in the service hierarchy",
+	listLayerLevels,
+	mcp.WithTitleAnnotation("List layer levels"),
+)
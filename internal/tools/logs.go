@@ -0,0 +1,207 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/log"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultLogPageSize      = 20
+	maxLogPageSize          = 100
+	defaultMaxContentLength = 2000
+)
+
+type QueryLogsRequest struct {
+	TimeRange
+	EntitySelector
+	TraceID          string   `json:"trace_id"`
+	SegmentID        string   `json:"segment_id"`
+	SpanID           *int     `json:"span_id"`
+	Tags             []string `json:"tags"`
+	Keywords         []string `json:"keywords"`
+	ExcludeKeywords  []string `json:"exclude_keywords"`
+	Order            string   `json:"order"`
+	PageNum          int      `json:"page_num"`
+	PageSize         int      `json:"page_size"`
+	MaxContentLength int      `json:"max_content_length"`
+}
+
+// LogList is a page of logs. HasMore tells whether the next page may have more logs.
+type LogList struct {
+	PageNum int        `json:"page_num"`
+	HasMore bool       `json:"has_more"`
+	Logs    []LogEntry `json:"logs"`
+}
+
+// LogEntry is a log with its content type, i.e. TEXT, JSON or YAML. Content longer than the maximum
+// length is truncated, the length being that of the whole content.
+type LogEntry struct {
+	Time          string            `json:"time"`
+	Service       string            `json:"service,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	Endpoint      string            `json:"endpoint,omitempty"`
+	TraceID       string            `json:"trace_id,omitempty"`
+	ContentType   api.ContentType   `json:"content_type"`
+	Content       string            `json:"content"`
+	Truncated     bool              `json:"truncated,omitempty"`
+	ContentLength int               `json:"content_length,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func queryLogs(ctx context.Context, req QueryLogsRequest) (*LogList, error) {
+	condition, err := req.toQueryCondition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := log.Logs(ctx, condition)
+	if err != nil {
+		return nil, fmt.Errorf("query logs failed: %w", err)
+	}
+
+	maxLength := req.MaxContentLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxContentLength
+	}
+	location := clockOf(ctx).location
+	result := &LogList{
+		PageNum: *condition.Paging.PageNum,
+		HasMore: len(logs.Logs) == condition.Paging.PageSize,
+		Logs:    make([]LogEntry, 0, len(logs.Logs)),
+	}
+	for _, l := range logs.Logs {
+		if l == nil {
+			continue
+		}
+		entry := LogEntry{
+			Time:        time.UnixMilli(l.Timestamp).In(location).Format(timestampLayout),
+			Service:     stringValue(l.ServiceName),
+			Instance:    stringValue(l.ServiceInstanceName),
+			Endpoint:    stringValue(l.EndpointName),
+			TraceID:     stringValue(l.TraceID),
+			ContentType: l.ContentType,
+			Content:     stringValue(l.Content),
+		}
+		if len(entry.Content) > maxLength {
+			entry.Truncated, entry.ContentLength = true, len(entry.Content)
+			entry.Content = truncateString(entry.Content, maxLength)
+		}
+		if len(l.Tags) > 0 {
+			entry.Tags = make(map[string]string, len(l.Tags))
+			for _, tag := range l.Tags {
+				if tag != nil {
+					entry.Tags[tag.Key] = stringValue(tag.Value)
+				}
+			}
+		}
+		result.Logs = append(result.Logs, entry)
+	}
+	return result, nil
+}
+
+// toQueryCondition builds the OAP log query. The time window may be left out when looking for the logs of a trace.
+func (r *QueryLogsRequest) toQueryCondition(ctx context.Context) (*api.LogQueryCondition, error) {
+	pageNum := max(r.PageNum, 1)
+	pageSize := min(r.PageSize, maxLogPageSize)
+	if pageSize <= 0 {
+		pageSize = defaultLogPageSize
+	}
+	order := api.OrderDes
+	if r.Order != "" {
+		order = api.Order(strings.ToUpper(r.Order))
+		if !order.IsValid() {
+			return nil, fmt.Errorf("invalid order %q, expected DES or ASC", r.Order)
+		}
+	}
+
+	condition := &api.LogQueryCondition{
+		Paging:                     &api.Pagination{PageNum: &pageNum, PageSize: pageSize},
+		KeywordsOfContent:          r.Keywords,
+		ExcludingKeywordsOfContent: r.ExcludeKeywords,
+		QueryOrder:                 &order,
+	}
+	if r.TraceID == "" || r.Start != "" || r.End != "" {
+		duration, err := r.toDuration(ctx)
+		if err != nil {
+			return nil, err
+		}
+		condition.QueryDuration = &duration
+	}
+	if r.TraceID != "" {
+		condition.RelatedTrace = &api.TraceScopeCondition{TraceID: r.TraceID, SpanID: r.SpanID}
+		if r.SegmentID != "" {
+			condition.RelatedTrace.SegmentID = &r.SegmentID
+		}
+	} else if r.SegmentID != "" || r.SpanID != nil {
+		return nil, fmt.Errorf("segment_id and span_id require trace_id")
+	}
+	tags, err := parseTags(r.Tags, func(key string, value *string) *api.LogTag {
+		return &api.LogTag{Key: key, Value: value}
+	})
+	if err != nil {
+		return nil, err
+	}
+	condition.Tags = tags
+
+	if condition.ServiceID, condition.ServiceInstanceID, condition.EndpointID, err = r.entityIDs(ctx); err != nil {
+		return nil, err
+	}
+	return condition, nil
+}
+
+func AddLogTools(mcp *server.MCPServer) {
+	QueryLogsTool.Register(mcp)
+}
+
+var QueryLogsTool = NewTool[QueryLogsRequest, *LogList](
+	"query_logs",
+	"Query the logs collected by SkyWalking, filtered by service, instance, endpoint, trace, tags and keywords, "+
+		"e.g. the logs of a failing trace or the ERROR logs of a service. Each log has its content type (TEXT, JSON or YAML), "+
+		"long content being truncated",
+	queryLogs,
+	mcp.WithTitleAnnotation("Query logs"),
+	mcp.WithString("service_id", mcp.Description("The ID of the service")),
+	mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+	mcp.WithString("service_instance_id", mcp.Description("The ID of the service instance")),
+	mcp.WithString("service_instance_name", mcp.Description("The name of the service instance, partial names are resolved, requires the service")),
+	mcp.WithString("endpoint_id", mcp.Description("The ID of the endpoint")),
+	mcp.WithString("endpoint_name", mcp.Description("The name of the endpoint, partial names are resolved, requires the service")),
+	mcp.WithString("trace_id", mcp.Description("The ID of the trace the logs belong to, the time window being optional then")),
+	mcp.WithString("segment_id", mcp.Description("The ID of the segment the logs belong to, requires trace_id")),
+	mcp.WithNumber("span_id", mcp.Description("The ID of the span the logs belong to, requires trace_id")),
+	mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("Tags the logs must carry, each as key=value, e.g. level=ERROR")),
+	mcp.WithArray("keywords", mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("Keywords the content must contain. Only supported by some storages, e.g. Elasticsearch")),
+	mcp.WithArray("exclude_keywords", mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("Keywords the content must not contain. Only supported by some storages, e.g. Elasticsearch")),
+	mcp.WithString("order", mcp.Enum(string(api.OrderDes), string(api.OrderAsc)),
+		mcp.Description("DES for the latest logs first, ASC for the oldest first. Defaults to DES")),
+	mcp.WithNumber("page_num", mcp.Description("The page number, defaults to 1")),
+	mcp.WithNumber("page_size", mcp.Description("The number of logs per page, defaults to 20, at most 100")),
+	mcp.WithNumber("max_content_length", mcp.Description("The length beyond which the content is truncated, defaults to 2000 bytes")),
+	WithTimeRange(),
+)
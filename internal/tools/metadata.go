@@ -0,0 +1,187 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// maxAttributeLength caps instance attributes such as the jar dependencies, which can be very long.
+const maxAttributeLength = 256
+
+type ListServicesRequest struct {
+	Layer string `json:"layer"`
+}
+
+type ListInstancesRequest struct {
+	TimeRange
+	ServiceSelector
+}
+
+// ServiceInstances lists the instances of a service.
+type ServiceInstances struct {
+	ServiceID   string         `json:"service_id"`
+	ServiceName string         `json:"service_name"`
+	Instances   []InstanceInfo `json:"instances"`
+}
+
+// InstanceInfo is a service instance with its attributes, e.g. hostname, pod, ipv4s and OS name.
+type InstanceInfo struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Language     api.Language      `json:"language"`
+	InstanceUUID string            `json:"instance_uuid"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+func listLayers(ctx context.Context, _ struct{}) ([]string, error) {
+	layers, err := metadata.ListLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list layers failed: %w", err)
+	}
+	sort.Strings(layers)
+	return layers, nil
+}
+
+func listServices(ctx context.Context, req ListServicesRequest) ([]api.Service, error) {
+	layers := []string{strings.ToUpper(req.Layer)}
+	if req.Layer == "" {
+		var err error
+		if layers, err = metadata.ListLayers(ctx); err != nil {
+			return nil, fmt.Errorf("list layers failed: %w", err)
+		}
+	}
+
+	// services of several layers are listed once per layer
+	var services []api.Service
+	seen := make(map[string]bool)
+	for _, layer := range layers {
+		layerServices, err := metadata.ListLayerService(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("list services of layer %v failed: %w", layer, err)
+		}
+		for _, service := range layerServices {
+			if !seen[service.ID] {
+				seen[service.ID] = true
+				services = append(services, service)
+			}
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services, nil
+}
+
+func listServiceInstances(ctx context.Context, req ListInstancesRequest) (*ServiceInstances, error) {
+	serviceID, serviceName, err := resolveService(ctx, req.ServiceID, req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := req.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := metadata.Instances(ctx, serviceID, duration)
+	if err != nil {
+		return nil, fmt.Errorf("list instances of service %v failed: %w", serviceName, err)
+	}
+
+	result := &ServiceInstances{
+		ServiceID:   serviceID,
+		ServiceName: serviceName,
+		Instances:   make([]InstanceInfo, 0, len(instances)),
+	}
+	for _, instance := range instances {
+		info := InstanceInfo{
+			ID:           instance.ID,
+			Name:         instance.Name,
+			Language:     instance.Language,
+			InstanceUUID: instance.InstanceUUID,
+		}
+		if len(instance.Attributes) > 0 {
+			info.Attributes = make(map[string]string, len(instance.Attributes))
+			for _, attribute := range instance.Attributes {
+				if attribute != nil {
+					info.Attributes[attribute.Name] = truncateString(attribute.Value, maxAttributeLength)
+				}
+			}
+		}
+		result.Instances = append(result.Instances, info)
+	}
+	return result, nil
+}
+
+// truncateString cuts s to at most n bytes without splitting a UTF-8 character.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+func AddMetadataTools(mcp *server.MCPServer) {
+	ListLayersTool.Register(mcp)
+	ListServicesTool.Register(mcp)
+	ListServiceInstancesTool.Register(mcp)
+	SearchEndpointsTool.Register(mcp)
+	ServiceHierarchyTool.Register(mcp)
+	InstanceHierarchyTool.Register(mcp)
+	ListLayerLevelsTool.Register(mcp)
+}
+
+var ListLayersTool = NewTool[struct{}, []string](
+	"list_layers",
+	"List the layers monitored by SkyWalking, e.g. GENERAL, MESH, K8S_SERVICE, MYSQL. Start an investigation from here",
+	listLayers,
+	mcp.WithTitleAnnotation("List layers"),
+)
+
+var ListServicesTool = NewTool[ListServicesRequest, []api.Service](
+	"list_services",
+	"List the services of a layer with their ID, name, group, short name, layers and whether they are normal "+
+		"(observed by agents) or unnormal (detected from the calls of other services, e.g. databases)",
+	listServices,
+	mcp.WithTitleAnnotation("List services"),
+	mcp.WithString("layer", mcp.Description("The layer of the services, as returned by list_layers. All layers when omitted")),
+)
+
+var ListServiceInstancesTool = NewTool[ListInstancesRequest, *ServiceInstances](
+	"list_service_instances",
+	"List the instances of a service in a time window with their language, instance UUID and attributes "+
+		"such as hostname, pod, ipv4s and OS, e.g. to map an instance to a Kubernetes pod",
+	listServiceInstances,
+	mcp.WithTitleAnnotation("List service instances"),
+	mcp.WithString("service_id", mcp.Description("The ID of the service")),
+	mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+	WithTimeRange(),
+)
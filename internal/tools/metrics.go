@@ -0,0 +1,380 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metrics"
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// defaultMetricLimit caps the metrics listed, as OAP defines more than a thousand of them.
+const defaultMetricLimit = 100
+
+// scopePrefixes infer the scope of a metric from its name, the longest prefixes first.
+var scopePrefixes = []struct {
+	prefix string
+	scope  api.Scope
+}{
+	{"service_instance_relation_", api.ScopeServiceInstanceRelation},
+	{"service_relation_", api.ScopeServiceRelation},
+	{"endpoint_relation_", api.ScopeEndpointRelation},
+	{"process_relation_", api.ScopeProcessRelation},
+	{"service_instance_", api.ScopeServiceInstance},
+	{"instance_", api.ScopeServiceInstance},
+	{"endpoint_", api.ScopeEndpoint},
+	{"process_", api.ScopeProcess},
+	{"service_", api.ScopeService},
+}
+
+type ListMetricsRequest struct {
+	Regex string `json:"regex"`
+	Scope string `json:"scope"`
+	Type  string `json:"type"`
+	Limit int    `json:"limit"`
+}
+
+// MetricCatalog lists the metrics matching a regex, with the total number of matching metrics.
+type MetricCatalog struct {
+	Total   int          `json:"total"`
+	Metrics []MetricInfo `json:"metrics"`
+}
+
+// MetricInfo is a metric with its value type and the scope of the entities it is computed for.
+type MetricInfo struct {
+	Name  string          `json:"name"`
+	Type  api.MetricsType `json:"type"`
+	Scope api.Scope       `json:"scope,omitempty"`
+}
+
+type ExecuteMQERequest struct {
+	MetricsEntity
+	TimeRange
+	ResultTransform
+	OutputFormat
+	Expression string `json:"expression"`
+}
+
+// MQEResult is the compact result of a metrics query expression. A single value without labels
+// is returned as the value, otherwise each labeled series is returned according to the type,
+// unless they are transformed into percentiles or heatmaps.
+type MQEResult struct {
+	Expression  string                   `json:"expression"`
+	Type        api.ExpressionResultType `json:"type"`
+	Start       string                   `json:"start,omitempty"`
+	Step        api.Step                 `json:"step,omitempty"`
+	Value       *float64                 `json:"value,omitempty"`
+	Series      []MQESeries              `json:"series,omitempty"`
+	Percentiles []PercentileTable        `json:"percentiles,omitempty"`
+	Heatmap     []HeatmapSummary         `json:"heatmap,omitempty"`
+}
+
+// MQESeries is a result series: a single value, time series values with one value per step
+// from the start of the result, or the items of a sorted or record list.
+type MQESeries struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  *float64          `json:"value,omitempty"`
+	Values []*float64        `json:"values,omitempty"`
+	Items  []MQEItem         `json:"items,omitempty"`
+}
+
+// MQEItem is an entity of a sorted list, or a record of a record list.
+type MQEItem struct {
+	Name    string   `json:"name"`
+	Value   *float64 `json:"value"`
+	TraceID string   `json:"trace_id,omitempty"`
+}
+
+func executeMQE(ctx context.Context, req ExecuteMQERequest) (*MQEResult, error) {
+	if req.Expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	entity, err := req.toEntity()
+	if err != nil {
+		return nil, err
+	}
+	duration, err := req.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := execMQE(ctx, req.Expression, entity, duration)
+	if err != nil {
+		return nil, err
+	}
+	mqeResult := toMQEResult(req.Expression, duration, result)
+	if err := transformResult(mqeResult, req.Transform); err != nil {
+		return nil, err
+	}
+	return mqeResult, nil
+}
+
+// toMQEResult converts the result of an expression into its compact form.
+func toMQEResult(expression string, duration api.Duration, result *api.ExpressionResult) *MQEResult {
+	mqeResult := &MQEResult{Expression: expression, Type: result.Type, Series: make([]MQESeries, 0, len(result.Results))}
+	if result.Type == api.ExpressionResultTypeTimeSeriesValues {
+		mqeResult.Start, mqeResult.Step = duration.Start, duration.Step
+	}
+
+	for _, values := range result.Results {
+		if values == nil {
+			continue
+		}
+		series := MQESeries{Labels: mqeLabels(values.Metric)}
+		switch result.Type {
+		case api.ExpressionResultTypeSingleValue:
+			if len(values.Values) > 0 {
+				if v, ok := parseMQEValue(values.Values[0]); ok {
+					series.Value = &v
+				}
+			}
+		case api.ExpressionResultTypeSortedList, api.ExpressionResultTypeRecordList:
+			series.Items = make([]MQEItem, 0, len(values.Values))
+			for _, value := range values.Values {
+				if value != nil {
+					series.Items = append(series.Items, mqeItem(value))
+				}
+			}
+		default:
+			series.Values = make([]*float64, len(values.Values))
+			for i, value := range values.Values {
+				if v, ok := parseMQEValue(value); ok {
+					series.Values[i] = &v
+				}
+			}
+		}
+		mqeResult.Series = append(mqeResult.Series, series)
+	}
+
+	if result.Type == api.ExpressionResultTypeSingleValue && len(mqeResult.Series) == 1 && mqeResult.Series[0].Labels == nil {
+		mqeResult.Value, mqeResult.Series = mqeResult.Series[0].Value, nil
+	}
+	return mqeResult
+}
+
+// TimeSeries returns the time series of the result, including the columns of its percentile tables.
+func (r *MQEResult) TimeSeries() []NamedSeries {
+	if r.Type != api.ExpressionResultTypeTimeSeriesValues {
+		return nil
+	}
+	var series []NamedSeries
+	for _, s := range r.Series {
+		series = append(series, NamedSeries{Name: r.Expression + formatLabels(s.Labels), Start: r.Start, Step: r.Step, Values: s.Values})
+	}
+	for _, table := range r.Percentiles {
+		for c, column := range table.Columns {
+			values := make([]*float64, len(table.Rows))
+			for i, row := range table.Rows {
+				values[i] = row[c]
+			}
+			name := r.Expression + formatLabels(table.Labels) + " " + column
+			series = append(series, NamedSeries{Name: name, Start: r.Start, Step: r.Step, Values: values})
+		}
+	}
+	return series
+}
+
+// formatLabels formats labels as in MQE, e.g. {p=50,service=a}, in the order of their keys.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// seriesPoint is a present value of a metric series.
+type seriesPoint struct {
+	time  time.Time
+	value float64
+}
+
+// labeledSeries is a time series of an expression result, without its missing values.
+type labeledSeries struct {
+	labels map[string]string
+	points []seriesPoint
+}
+
+// querySeries executes an expression expected to return time series from the start to the end,
+// placing each value at the time of its bucket in the timezone of OAP.
+func querySeries(ctx context.Context, expression string, entity *api.Entity, start, end time.Time, step api.Step) ([]labeledSeries, error) {
+	layout := utils.StepFormats[step]
+	duration := api.Duration{Start: start.Format(layout), End: end.Format(layout), Step: step}
+	result, err := execMQE(ctx, expression, entity, duration)
+	if err != nil {
+		return nil, err
+	}
+	if result.Type != api.ExpressionResultTypeTimeSeriesValues {
+		return nil, fmt.Errorf("expression %v returns %v, time series values are required", expression, result.Type)
+	}
+
+	first, err := time.ParseInLocation(layout, duration.Start, clockOf(ctx).location)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]labeledSeries, 0, len(result.Results))
+	for _, values := range result.Results {
+		if values == nil {
+			continue
+		}
+		s := labeledSeries{labels: mqeLabels(values.Metric)}
+		for i, value := range values.Values {
+			if v, ok := parseMQEValue(value); ok {
+				s.points = append(s.points, seriesPoint{time: first.Add(time.Duration(i) * utils.StepDuration[step]), value: v})
+			}
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+func mqeLabels(metric *api.Metadata) map[string]string {
+	if metric == nil || len(metric.Labels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(metric.Labels))
+	for _, label := range metric.Labels {
+		if label != nil {
+			labels[label.Key] = stringValue(label.Value)
+		}
+	}
+	return labels
+}
+
+// mqeItem names an item after its owner, the most specific name first, falling back to its ID.
+func mqeItem(value *api.MQEValue) MQEItem {
+	item := MQEItem{Name: stringValue(value.ID), TraceID: stringValue(value.TraceID)}
+	if owner := value.Owner; owner != nil {
+		for _, name := range []*string{owner.EndpointName, owner.ServiceInstanceName, owner.ServiceName} {
+			if name != nil && *name != "" {
+				item.Name = *name
+				break
+			}
+		}
+	}
+	if v, ok := parseMQEValue(value); ok {
+		item.Value = &v
+	}
+	return item
+}
+
+func listMetrics(ctx context.Context, req ListMetricsRequest) (*MetricCatalog, error) {
+	definitions, err := metrics.ListMetrics(ctx, req.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics matching %q failed: %w", req.Regex, err)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultMetricLimit
+	}
+
+	catalog := &MetricCatalog{Metrics: []MetricInfo{}}
+	for _, definition := range definitions {
+		if definition == nil {
+			continue
+		}
+		info := MetricInfo{Name: definition.Name, Type: definition.Type, Scope: metricScope(definition)}
+		if req.Scope != "" && !strings.EqualFold(string(info.Scope), req.Scope) ||
+			req.Type != "" && !strings.EqualFold(string(info.Type), req.Type) {
+			continue
+		}
+		catalog.Total++
+		catalog.Metrics = append(catalog.Metrics, info)
+	}
+	sort.Slice(catalog.Metrics, func(i, j int) bool {
+		return catalog.Metrics[i].Name < catalog.Metrics[j].Name
+	})
+	catalog.Metrics = catalog.Metrics[:min(limit, len(catalog.Metrics))]
+	return catalog, nil
+}
+
+// metricScope returns the scope of a metric given by its catalog, e.g. SERVICE_INSTANCE,
+// or inferred from its name when OAP does not tell it.
+func metricScope(definition *api.MetricDefinition) api.Scope {
+	if definition.Catalog != nil {
+		catalog := strings.ReplaceAll(*definition.Catalog, "_", "")
+		for _, scope := range api.AllScope {
+			if strings.EqualFold(string(scope), catalog) {
+				return scope
+			}
+		}
+	}
+	return scopeFromName(definition.Name)
+}
+
+// scopeFromName infers the scope of a metric from the naming convention of OAL, e.g.
+// service_instance_relation_client_cpm, returning an empty scope when the name does not tell it.
+func scopeFromName(name string) api.Scope {
+	for _, prefix := range scopePrefixes {
+		if strings.HasPrefix(name, prefix.prefix) {
+			return prefix.scope
+		}
+	}
+	return ""
+}
+
+func AddMetricsTools(mcp *server.MCPServer) {
+	ListMetricsTool.Register(mcp)
+	ExecuteMQETool.Register(mcp)
+	TopNTool.Register(mcp)
+	DetectAnomaliesTool.Register(mcp)
+	CompareWindowsTool.Register(mcp)
+	CorrelateMetricsTool.Register(mcp)
+}
+
+var ListMetricsTool = NewTool[ListMetricsRequest, *MetricCatalog](
+	"list_metrics",
+	"List the metrics defined in OAP with their type and scope, to build valid MQE expressions instead of guessing metric names. "+
+		"REGULAR_VALUE metrics are used as is, e.g. 'service_resp_time', LABELED_VALUE metrics select labels, "+
+		"e.g. 'service_percentile{p=\"50,99\"}', and HEATMAP metrics are histograms",
+	listMetrics,
+	mcp.WithTitleAnnotation("List metrics"),
+	mcp.WithString("regex",
+		mcp.Description("Regular expression the metric names match, e.g. 'service_.*' or '.*resp_time.*'. All metrics when omitted")),
+	mcp.WithString("scope", mcp.Description("Only list the metrics of this scope, e.g. Service, ServiceInstance or Endpoint")),
+	mcp.WithString("type", mcp.Enum(string(api.MetricsTypeRegularValue), string(api.MetricsTypeLabeledValue),
+		string(api.MetricsTypeHeatmap), string(api.MetricsTypeSampledRecord)),
+		mcp.Description("Only list the metrics of this type")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of metrics, defaults to 100")),
+)
+
+var ExecuteMQETool = NewTool[ExecuteMQERequest, *MQEResult](
+	"execute_mqe",
+	"Execute a Metrics Query Expression (MQE) against an entity over a time window, e.g. 'service_resp_time', "+
+		"'avg(service_cpm)', 'service_percentile{p=\"50,99\"}' or 'top_n(service_cpm, 10, des)'. "+
+		"The result type tells how to read it: SINGLE_VALUE has a value, TIME_SERIES_VALUES has one value per step "+
+		"from start with null for missing data, SORTED_LIST and RECORD_LIST have named items",
+	executeMQE,
+	mcp.WithTitleAnnotation("Execute MQE"),
+	mcp.WithString("expression", mcp.Required(), mcp.Description("The metrics query expression")),
+	WithMetricsEntity(),
+	WithTimeRange(),
+	WithResultTransform(),
+	WithOutputFormat(FormatSparkline, FormatChart),
+)
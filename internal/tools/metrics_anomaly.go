@@ -0,0 +1,176 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultAnomalyThreshold = 3.5
+	defaultBaseline         = "24h"
+	maxAnomalies            = 50
+)
+
+var anomalyDetectors = []string{DetectorMAD, DetectorEWMA, DetectorSeasonal}
+
+type DetectAnomaliesRequest struct {
+	MetricsEntity
+	TimeRange
+	Expression string   `json:"expression"`
+	Baseline   string   `json:"baseline"`
+	Threshold  float64  `json:"threshold"`
+	Detectors  []string `json:"detectors"`
+}
+
+// AnomalyReport lists the anomalous points of the time window, the most anomalous first.
+type AnomalyReport struct {
+	Expression     string    `json:"expression"`
+	Step           api.Step  `json:"step"`
+	Detectors      []string  `json:"detectors"`
+	Threshold      float64   `json:"threshold"`
+	Points         int       `json:"points"`
+	BaselinePoints int       `json:"baseline_points"`
+	Total          int       `json:"total"`
+	Anomalies      []Anomaly `json:"anomalies"`
+}
+
+// Anomaly is a point far from what the detectors expected. The score is the distance in robust
+// standard deviations given by the detector scoring it the highest.
+type Anomaly struct {
+	Labels    map[string]string `json:"labels,omitempty"`
+	Time      string            `json:"time"`
+	Value     float64           `json:"value"`
+	Expected  float64           `json:"expected"`
+	Score     float64           `json:"score"`
+	Direction string            `json:"direction"`
+	Detectors []string          `json:"detectors"`
+}
+
+func detectMetricAnomalies(ctx context.Context, req DetectAnomaliesRequest) (*AnomalyReport, error) {
+	if req.Expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	threshold := req.Threshold
+	if threshold <= 0 {
+		threshold = defaultAnomalyThreshold
+	}
+	detectors := req.Detectors
+	if len(detectors) == 0 {
+		detectors = anomalyDetectors
+	}
+	for _, detector := range detectors {
+		if !slices.Contains(anomalyDetectors, detector) {
+			return nil, fmt.Errorf("invalid detector %q, expected one of %v", detector, anomalyDetectors)
+		}
+	}
+	baseline := req.Baseline
+	if baseline == "" {
+		baseline = defaultBaseline
+	}
+	lookback, ok, err := parseRelative(baseline)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("invalid baseline %q, expected a duration such as '24h' or '7d'", req.Baseline)
+	}
+
+	entity, err := req.toEntity()
+	if err != nil {
+		return nil, err
+	}
+	start, end, step, err := req.bounds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	series, err := querySeries(ctx, req.Expression, entity, start.Add(-lookback), end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	// the window starts at the bucket of its start, which is also how OAP aligns the series
+	layout := utils.StepFormats[step]
+	windowStart, err := time.ParseInLocation(layout, start.Format(layout), start.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AnomalyReport{Expression: req.Expression, Step: step, Detectors: detectors, Threshold: threshold, Anomalies: []Anomaly{}}
+	for _, s := range series {
+		split := sort.Search(len(s.points), func(i int) bool {
+			return !s.points[i].time.Before(windowStart)
+		})
+		baselinePoints, window := s.points[:split], s.points[split:]
+		report.Points += len(window)
+		report.BaselinePoints += len(baselinePoints)
+
+		for _, point := range detectAnomalies(baselinePoints, window, threshold, detectors) {
+			direction := "up"
+			if point.score < 0 {
+				direction = "down"
+			}
+			report.Anomalies = append(report.Anomalies, Anomaly{
+				Labels:    s.labels,
+				Time:      window[point.index].time.Format(layout),
+				Value:     window[point.index].value,
+				Expected:  roundFloat(point.expected, 3),
+				Score:     roundFloat(point.score, 2),
+				Direction: direction,
+				Detectors: point.detectors,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Anomalies, func(i, j int) bool {
+		return math.Abs(report.Anomalies[i].Score) > math.Abs(report.Anomalies[j].Score)
+	})
+	report.Total = len(report.Anomalies)
+	report.Anomalies = report.Anomalies[:min(len(report.Anomalies), maxAnomalies)]
+	return report, nil
+}
+
+// roundFloat rounds the value to the given number of decimals.
+func roundFloat(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
+
+var DetectAnomaliesTool = NewTool[DetectAnomaliesRequest, *AnomalyReport](
+	"detect_metric_anomalies",
+	"Detect the anomalous points of a metric series in the time window against a baseline before it, "+
+		"e.g. latency spikes or drops of throughput. Detectors: mad compares with the median of the baseline, "+
+		"ewma with the recent trend, seasonal with the same hour of the day in the baseline. "+
+		"Each anomaly has its expected value, a score in robust standard deviations and a direction",
+	detectMetricAnomalies,
+	mcp.WithTitleAnnotation("Detect metric anomalies"),
+	mcp.WithString("expression", mcp.Required(),
+		mcp.Description("The metric or MQE expression returning time series, e.g. service_resp_time or service_percentile{p='99'}")),
+	mcp.WithString("baseline", mcp.Description("How far before the window the baseline goes, e.g. '6h' or '7d'. Defaults to 24h")),
+	mcp.WithNumber("threshold", mcp.Description("Score from which a point is anomalous, defaults to 3.5")),
+	mcp.WithArray("detectors", mcp.Items(map[string]any{"type": "string", "enum": anomalyDetectors}),
+		mcp.Description("The detectors to run, all of them when omitted")),
+	WithMetricsEntity(),
+	WithTimeRange(),
+)
@@ -0,0 +1,255 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/event"
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultComparisonWindow = "1h"
+	// eventLookback is how far back the latest event serving as pivot is searched.
+	eventLookback = 7 * 24 * time.Hour
+	// significanceLevel is the p-value under which a change is significant.
+	significanceLevel = 0.05
+	// minComparedPoints is the number of points each window needs for the test to be meaningful.
+	minComparedPoints  = 3
+	compareConcurrency = 4
+)
+
+type CompareWindowsRequest struct {
+	MetricsEntity
+	Expressions []string `json:"expressions"`
+	Pivot       string   `json:"pivot"`
+	Event       string   `json:"event"`
+	Window      string   `json:"window"`
+}
+
+// WindowComparison compares the metrics of an entity in the windows before and after a pivot time.
+type WindowComparison struct {
+	Pivot   string             `json:"pivot"`
+	Event   string             `json:"event,omitempty"`
+	Step    api.Step           `json:"step"`
+	Before  TimeWindow         `json:"before"`
+	After   TimeWindow         `json:"after"`
+	Metrics []MetricComparison `json:"metrics"`
+}
+
+// TimeWindow is a window of time, formatted according to the step.
+type TimeWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// MetricComparison is the change of a metric series between the two windows. The p-value is
+// the two-sided Mann-Whitney U test of the values of the windows being from the same distribution.
+type MetricComparison struct {
+	Expression  string            `json:"expression"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Before      *WindowStats      `json:"before,omitempty"`
+	After       *WindowStats      `json:"after,omitempty"`
+	Change      *float64          `json:"change,omitempty"`
+	ChangeRate  *float64          `json:"change_rate,omitempty"`
+	PValue      *float64          `json:"p_value,omitempty"`
+	Significant bool              `json:"significant"`
+	Direction   string            `json:"direction,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// WindowStats summarizes the values of a window.
+type WindowStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+func compareWindows(ctx context.Context, req CompareWindowsRequest) (*WindowComparison, error) {
+	if len(req.Expressions) == 0 {
+		return nil, fmt.Errorf("at least one expression is required")
+	}
+	window := req.Window
+	if window == "" {
+		window = defaultComparisonWindow
+	}
+	length, ok, err := parseRelative(window)
+	if !ok || err != nil || length <= 0 {
+		return nil, fmt.Errorf("invalid window %q, expected a duration such as '30m' or '2h'", req.Window)
+	}
+	entity, err := req.toEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	clock := clockOf(ctx)
+	pivot, err := comparisonPivot(ctx, req, entity, clock)
+	if err != nil {
+		return nil, err
+	}
+	step := stepFor(length)
+	layout := utils.StepFormats[step]
+	start, end := pivot.Add(-length), pivot.Add(length)
+	if now := clock.now(); end.After(now) {
+		end = now
+	}
+	if !end.After(pivot) {
+		return nil, fmt.Errorf("pivot %v is in the future", pivot.Format(layout))
+	}
+	// the after window starts at the bucket of the pivot
+	pivotBucket, err := time.ParseInLocation(layout, pivot.Format(layout), pivot.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &WindowComparison{
+		Pivot:  pivot.Format(layout),
+		Event:  req.Event,
+		Step:   step,
+		Before: TimeWindow{Start: start.Format(layout), End: pivotBucket.Add(-utils.StepDuration[step]).Format(layout)},
+		After:  TimeWindow{Start: pivotBucket.Format(layout), End: end.Format(layout)},
+	}
+	results := make([][]MetricComparison, len(req.Expressions))
+	runConcurrently(len(req.Expressions), compareConcurrency, func(i int) {
+		expression := req.Expressions[i]
+		series, err := querySeries(ctx, expression, entity, start, end, step)
+		if err != nil {
+			results[i] = []MetricComparison{{Expression: expression, Error: err.Error()}}
+			return
+		}
+		for _, s := range series {
+			results[i] = append(results[i], compareSeries(expression, s, pivotBucket))
+		}
+	})
+	for _, result := range results {
+		comparison.Metrics = append(comparison.Metrics, result...)
+	}
+	return comparison, nil
+}
+
+// comparisonPivot returns the pivot time, given or the start of the latest event of the service with the given name.
+func comparisonPivot(ctx context.Context, req CompareWindowsRequest, entity *api.Entity, clock *oapClock) (time.Time, error) {
+	switch {
+	case req.Pivot != "" && req.Event != "":
+		return time.Time{}, fmt.Errorf("either pivot or event is required, not both")
+	case req.Pivot != "":
+		return parseTime(req.Pivot, clock.now(), clock.location)
+	case req.Event == "":
+		return time.Time{}, fmt.Errorf("either pivot or event is required")
+	}
+
+	now := clock.now()
+	layout := utils.StepFormats[api.StepMinute]
+	order, pageNum := api.OrderDes, 1
+	condition := &api.EventQueryCondition{
+		Name:   &req.Event,
+		Time:   &api.Duration{Start: now.Add(-eventLookback).Format(layout), End: now.Format(layout), Step: api.StepMinute},
+		Order:  &order,
+		Paging: &api.Pagination{PageNum: &pageNum, PageSize: 1},
+	}
+	if entity.ServiceName != nil {
+		condition.Source = &api.SourceInput{Service: entity.ServiceName}
+	}
+	events, err := event.Events(ctx, condition)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("query events %v failed: %w", req.Event, err)
+	}
+	if len(events.Events) == 0 || events.Events[0] == nil {
+		return time.Time{}, fmt.Errorf("no event %v in the last %v", req.Event, eventLookback)
+	}
+	return time.UnixMilli(events.Events[0].StartTime).In(clock.location), nil
+}
+
+// compareSeries compares the points of a series before the pivot with the points from the pivot on.
+func compareSeries(expression string, s labeledSeries, pivot time.Time) MetricComparison {
+	comparison := MetricComparison{Expression: expression, Labels: s.labels}
+	var before, after []float64
+	for _, point := range s.points {
+		if point.time.Before(pivot) {
+			before = append(before, point.value)
+		} else {
+			after = append(after, point.value)
+		}
+	}
+	comparison.Before, comparison.After = windowStats(before), windowStats(after)
+	if comparison.Before == nil || comparison.After == nil {
+		return comparison
+	}
+
+	change := roundFloat(comparison.After.Mean-comparison.Before.Mean, 3)
+	comparison.Change = &change
+	if comparison.Before.Mean != 0 {
+		rate := roundFloat(change/comparison.Before.Mean, 4)
+		comparison.ChangeRate = &rate
+	}
+	if len(before) < minComparedPoints || len(after) < minComparedPoints {
+		return comparison
+	}
+	_, p := mannWhitneyU(after, before)
+	p = roundFloat(p, 4)
+	comparison.PValue = &p
+	comparison.Significant = p < significanceLevel
+	switch {
+	case !comparison.Significant:
+		comparison.Direction = "unchanged"
+	case comparison.After.Median > comparison.Before.Median || comparison.After.Median == comparison.Before.Median && change > 0:
+		comparison.Direction = "up"
+	default:
+		comparison.Direction = "down"
+	}
+	return comparison
+}
+
+func windowStats(values []float64) *WindowStats {
+	if len(values) == 0 {
+		return nil
+	}
+	stats := &WindowStats{Count: len(values), Min: values[0], Max: values[0]}
+	var sum float64
+	for _, v := range values {
+		sum += v
+		stats.Min, stats.Max = min(stats.Min, v), max(stats.Max, v)
+	}
+	stats.Mean = roundFloat(sum/float64(len(values)), 3)
+	stats.Median = median(values)
+	return stats
+}
+
+var CompareWindowsTool = NewTool[CompareWindowsRequest, *WindowComparison](
+	"compare_metric_windows",
+	"Compare metrics of an entity in the windows before and after a pivot time, e.g. a deployment, to tell whether it "+
+		"made things worse. Each metric has the statistics of both windows, the change of its mean, and the p-value "+
+		"of a Mann-Whitney U test, the change being significant below 0.05",
+	compareWindows,
+	mcp.WithTitleAnnotation("Compare metric windows"),
+	mcp.WithArray("expressions", mcp.Required(), mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("The metrics or MQE expressions returning time series, e.g. service_resp_time, service_sla, service_cpm")),
+	mcp.WithString("pivot",
+		mcp.Description("The pivot time, e.g. '2025-06-01T10:30:00Z' or relative to now such as '2h'. Either pivot or event is required")),
+	mcp.WithString("event",
+		mcp.Description("The name of an event such as Upgrade, the start of its latest occurrence for the service being the pivot")),
+	mcp.WithString("window", mcp.Description("The length of each window, e.g. '30m' or '2h'. Defaults to 1h")),
+	WithMetricsEntity(),
+)
@@ -0,0 +1,337 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/dependency"
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	"github.com/apache/skywalking-cli/pkg/graphql/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultMaxLag                 = 5
+	maxMaxLag                     = 30
+	defaultCorrelationLimit       = 10
+	defaultCorrelationConcurrency = 4
+	maxCorrelationConcurrency     = 10
+	// minCorrelationPoints is the number of paired points a correlation needs to be reported.
+	minCorrelationPoints = 10
+	// maxNeighbours and maxCorrelatedInstances bound the entities scanned.
+	maxNeighbours          = 20
+	maxCorrelatedInstances = 20
+)
+
+// Correlation methods.
+const (
+	CorrelationPearson  = "pearson"
+	CorrelationSpearman = "spearman"
+)
+
+// Relations of the candidate entities to the target service.
+const (
+	relationSelf       = "self"
+	relationUpstream   = "upstream"
+	relationDownstream = "downstream"
+	relationInstance   = "instance"
+)
+
+var (
+	defaultServiceCandidates  = []string{"service_resp_time", "service_cpm", "service_sla"}
+	defaultInstanceCandidates = []string{"service_instance_resp_time", "service_instance_cpm", "service_instance_sla"}
+)
+
+type CorrelateMetricsRequest struct {
+	MetricsEntity
+	TimeRange
+	Expression      string   `json:"expression"`
+	ServiceMetrics  []string `json:"service_metrics"`
+	InstanceMetrics []string `json:"instance_metrics"`
+	Method          string   `json:"method"`
+	MaxLag          int      `json:"max_lag"`
+	Limit           int      `json:"limit"`
+	Concurrency     int      `json:"concurrency"`
+}
+
+// CorrelationReport ranks the candidate series by the strength of their correlation with the target series.
+// Candidates whose metric returns several series, e.g. labeled metrics, are skipped.
+type CorrelationReport struct {
+	Target       string              `json:"target"`
+	Method       string              `json:"method"`
+	Step         api.Step            `json:"step"`
+	Scanned      int                 `json:"scanned"`
+	Failed       int                 `json:"failed"`
+	Skipped      int                 `json:"skipped"`
+	Correlations []MetricCorrelation `json:"correlations"`
+}
+
+// MetricCorrelation is a candidate series correlated with the target. A positive lag means the candidate
+// moves that many steps before the target, a negative one after it.
+type MetricCorrelation struct {
+	Metric      string  `json:"metric"`
+	Relation    string  `json:"relation"`
+	Service     string  `json:"service"`
+	Instance    string  `json:"instance,omitempty"`
+	Correlation float64 `json:"correlation"`
+	Lag         int     `json:"lag"`
+	Points      int     `json:"points"`
+}
+
+type correlationCandidate struct {
+	metric   string
+	relation string
+	entity   *api.Entity
+}
+
+func correlateMetrics(ctx context.Context, req CorrelateMetricsRequest) (*CorrelationReport, error) {
+	if req.Expression == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	method := req.Method
+	if method == "" {
+		method = CorrelationPearson
+	}
+	if method != CorrelationPearson && method != CorrelationSpearman {
+		return nil, fmt.Errorf("invalid method %q, expected pearson or spearman", req.Method)
+	}
+	if req.ServiceID == "" {
+		return nil, fmt.Errorf("the service of the target is required")
+	}
+	maxLag := min(req.MaxLag, maxMaxLag)
+	if maxLag <= 0 {
+		maxLag = defaultMaxLag
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultCorrelationLimit
+	}
+	concurrency := min(req.Concurrency, maxCorrelationConcurrency)
+	if concurrency <= 0 {
+		concurrency = defaultCorrelationConcurrency
+	}
+
+	entity, err := req.toEntity()
+	if err != nil {
+		return nil, err
+	}
+	start, end, step, err := req.bounds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := querySeries(ctx, req.Expression, entity, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 1 {
+		return nil, fmt.Errorf("the target expression must return a single series, %v returns %d", req.Expression, len(targets))
+	}
+	if len(targets) == 0 || len(targets[0].points) < minCorrelationPoints {
+		return nil, fmt.Errorf("expression %v has fewer than %d points in the time window", req.Expression, minCorrelationPoints)
+	}
+	target := targets[0].points
+
+	candidates, err := correlationCandidates(ctx, req, step, start, end)
+	if err != nil {
+		return nil, err
+	}
+	report := &CorrelationReport{Target: req.Expression, Method: method, Step: step, Scanned: len(candidates)}
+	results := make([]*MetricCorrelation, len(candidates))
+	failed, skipped := make([]bool, len(candidates)), make([]bool, len(candidates))
+	runConcurrently(len(candidates), concurrency, func(i int) {
+		candidate := candidates[i]
+		series, err := querySeries(ctx, candidate.metric, candidate.entity, start, end, step)
+		if err != nil || len(series) != 1 {
+			failed[i], skipped[i] = err != nil, len(series) > 1
+			return
+		}
+		r, lag, points, ok := laggedCorrelation(target, series[0].points, utils.StepDuration[step], maxLag, method)
+		if !ok {
+			return
+		}
+		results[i] = &MetricCorrelation{
+			Metric:      candidate.metric,
+			Relation:    candidate.relation,
+			Service:     stringValue(candidate.entity.ServiceName),
+			Instance:    stringValue(candidate.entity.ServiceInstanceName),
+			Correlation: roundFloat(r, 3),
+			Lag:         lag,
+			Points:      points,
+		}
+	})
+
+	report.Correlations = []MetricCorrelation{}
+	for i, result := range results {
+		if failed[i] {
+			report.Failed++
+		}
+		if skipped[i] {
+			report.Skipped++
+		}
+		if result != nil {
+			report.Correlations = append(report.Correlations, *result)
+		}
+	}
+	sort.SliceStable(report.Correlations, func(i, j int) bool {
+		return math.Abs(report.Correlations[i].Correlation) > math.Abs(report.Correlations[j].Correlation)
+	})
+	report.Correlations = report.Correlations[:min(limit, len(report.Correlations))]
+	return report, nil
+}
+
+// correlationCandidates lists the candidate series: the service metrics of the target service and its
+// topology neighbours, and the instance metrics of the instances of the target service.
+func correlationCandidates(ctx context.Context, req CorrelateMetricsRequest, step api.Step, start, end time.Time) ([]correlationCandidate, error) {
+	serviceMetrics, instanceMetrics := req.ServiceMetrics, req.InstanceMetrics
+	if len(serviceMetrics) == 0 {
+		serviceMetrics = defaultServiceCandidates
+	}
+	if len(instanceMetrics) == 0 {
+		instanceMetrics = defaultInstanceCandidates
+	}
+	layout := utils.StepFormats[step]
+	duration := api.Duration{Start: start.Format(layout), End: end.Format(layout), Step: step}
+
+	serviceName, normal, err := parseServiceID(req.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []correlationCandidate
+	addService := func(name string, normal bool, relation string) {
+		scope := api.ScopeService
+		for _, metric := range serviceMetrics {
+			if relation == relationSelf && metric == req.Expression {
+				continue
+			}
+			candidates = append(candidates, correlationCandidate{
+				metric:   metric,
+				relation: relation,
+				entity:   &api.Entity{Scope: &scope, ServiceName: &name, Normal: &normal},
+			})
+		}
+	}
+	addService(serviceName, normal, relationSelf)
+
+	topology, err := dependency.ServiceTopology(ctx, req.ServiceID, duration)
+	if err != nil {
+		return nil, fmt.Errorf("query topology of service %v failed: %w", serviceName, err)
+	}
+	relations := make(map[string]string)
+	for _, call := range topology.Calls {
+		switch {
+		case call == nil:
+		case call.Source == req.ServiceID:
+			relations[call.Target] = relationDownstream
+		case call.Target == req.ServiceID:
+			relations[call.Source] = relationUpstream
+		}
+	}
+	neighbours := 0
+	for _, node := range topology.Nodes {
+		if node == nil || node.ID == req.ServiceID || relations[node.ID] == "" || neighbours >= maxNeighbours {
+			continue
+		}
+		neighbours++
+		addService(node.Name, node.IsReal, relations[node.ID])
+	}
+
+	instances, err := metadata.Instances(ctx, req.ServiceID, duration)
+	if err != nil {
+		return nil, fmt.Errorf("list instances of service %v failed: %w", serviceName, err)
+	}
+	scope := api.ScopeServiceInstance
+	for _, instance := range instances[:min(len(instances), maxCorrelatedInstances)] {
+		for _, metric := range instanceMetrics {
+			if metric == req.Expression && instance.Name == req.InstanceName {
+				continue
+			}
+			candidates = append(candidates, correlationCandidate{
+				metric:   metric,
+				relation: relationInstance,
+				entity:   &api.Entity{Scope: &scope, ServiceName: &serviceName, Normal: &normal, ServiceInstanceName: &instance.Name},
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// laggedCorrelation correlates the candidate shifted by up to maxLag steps each way with the target,
+// returning the strongest correlation, the smallest lag winning ties, and the number of paired points.
+func laggedCorrelation(target, candidate []seriesPoint, step time.Duration, maxLag int, method string) (r float64, lag, points int, ok bool) {
+	values := make(map[int64]float64, len(candidate))
+	for _, point := range candidate {
+		values[point.time.UnixMilli()] = point.value
+	}
+
+	for _, l := range lagOrder(maxLag) {
+		var x, y []float64
+		for _, point := range target {
+			// a positive lag pairs the target with the candidate that many steps before
+			if v, found := values[point.time.Add(-time.Duration(l)*step).UnixMilli()]; found {
+				x, y = append(x, point.value), append(y, v)
+			}
+		}
+		if len(x) < minCorrelationPoints {
+			continue
+		}
+		correlate := pearson
+		if method == CorrelationSpearman {
+			correlate = spearman
+		}
+		if c, valid := correlate(x, y); valid && (!ok || math.Abs(c) > math.Abs(r)) {
+			r, lag, points, ok = c, l, len(x), true
+		}
+	}
+	return r, lag, points, ok
+}
+
+// lagOrder returns the lags from 0 to maxLag each way, the smallest first: 0, 1, -1, 2, -2...
+func lagOrder(maxLag int) []int {
+	lags := []int{0}
+	for l := 1; l <= maxLag; l++ {
+		lags = append(lags, l, -l)
+	}
+	return lags
+}
+
+var CorrelateMetricsTool = NewTool[CorrelateMetricsRequest, *CorrelationReport](
+	"correlate_metrics",
+	"Find the metrics that moved together with a target series, e.g. the service_resp_time of an alarming service. "+
+		"Scans the service metrics of the service and its upstream and downstream topology neighbours, and the instance "+
+		"metrics of its instances, ranked by the strongest lagged correlation, a positive lag meaning the candidate moved first",
+	correlateMetrics,
+	mcp.WithTitleAnnotation("Correlate metrics"),
+	mcp.WithString("expression", mcp.Required(), mcp.Description("The target metric or MQE expression returning a single time series, e.g. service_resp_time")),
+	mcp.WithArray("service_metrics", mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("The candidate metrics of the services, defaults to service_resp_time, service_cpm and service_sla")),
+	mcp.WithArray("instance_metrics", mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("The candidate metrics of the instances, defaults to service_instance_resp_time, service_instance_cpm and service_instance_sla")),
+	mcp.WithString("method", mcp.Enum(CorrelationPearson, CorrelationSpearman),
+		mcp.Description("pearson for linear relations, spearman for monotonic ones and robustness to spikes. Defaults to pearson")),
+	mcp.WithNumber("max_lag", mcp.Description("Maximum shift in steps tried each way, defaults to 5, at most 30")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of correlated series, defaults to 10")),
+	mcp.WithNumber("concurrency", mcp.Description("Maximum number of candidate queries in flight, defaults to 4, at most 10")),
+	WithMetricsEntity(),
+	WithTimeRange(),
+)
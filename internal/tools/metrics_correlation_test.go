@@ -0,0 +1,133 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// irregular returns n values without period, so that a shifted copy only lines up at one lag.
+func irregular(n int) []float64 {
+	values := make([]float64, n)
+	seed := 7
+	for i := range values {
+		seed = (seed*31 + 11) % 97
+		values[i] = float64(seed)
+	}
+	return values
+}
+
+// shifted moves the points the given number of steps later.
+func shifted(points []seriesPoint, step time.Duration, steps int) []seriesPoint {
+	result := make([]seriesPoint, len(points))
+	for i, point := range points {
+		result[i] = seriesPoint{time: point.time.Add(time.Duration(steps) * step), value: point.value}
+	}
+	return result
+}
+
+// mapped applies f to the values of the points.
+func mapped(points []seriesPoint, f func(float64) float64) []seriesPoint {
+	result := make([]seriesPoint, len(points))
+	for i, point := range points {
+		result[i] = seriesPoint{time: point.time, value: f(point.value)}
+	}
+	return result
+}
+
+func TestLaggedCorrelation(t *testing.T) {
+	step := time.Minute
+	candidate := series(step, irregular(40)...)
+	tests := []struct {
+		name       string
+		target     []seriesPoint
+		method     string
+		wantR      float64
+		wantLag    int
+		wantPoints int
+		wantOK     bool
+	}{
+		{name: "in step", target: candidate, method: CorrelationPearson, wantR: 1, wantPoints: 40, wantOK: true},
+		{
+			name:   "candidate first",
+			target: shifted(candidate, step, 3), method: CorrelationPearson,
+			wantR: 1, wantLag: 3, wantPoints: 40, wantOK: true,
+		},
+		{
+			name:   "target first",
+			target: shifted(candidate, step, -2), method: CorrelationPearson,
+			wantR: 1, wantLag: -2, wantPoints: 40, wantOK: true,
+		},
+		{
+			name:   "inverse",
+			target: mapped(candidate, func(v float64) float64 { return 100 - v }), method: CorrelationPearson,
+			wantR: -1, wantPoints: 40, wantOK: true,
+		},
+		{
+			name:   "monotonic with spearman",
+			target: mapped(candidate, func(v float64) float64 { return math.Exp(v / 10) }), method: CorrelationSpearman,
+			wantR: 1, wantPoints: 40, wantOK: true,
+		},
+		{name: "too few points", target: candidate[:minCorrelationPoints-1], method: CorrelationPearson, wantOK: false},
+		{name: "constant", target: series(step, flat(40, 5)...), method: CorrelationPearson, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, lag, points, ok := laggedCorrelation(tt.target, candidate, step, 5, tt.method)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v (r %v at lag %d)", ok, tt.wantOK, r, lag)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(r-tt.wantR) > 1e-9 || lag != tt.wantLag || points != tt.wantPoints {
+				t.Errorf("laggedCorrelation = %v at lag %d over %d points, want %v at lag %d over %d points",
+					r, lag, points, tt.wantR, tt.wantLag, tt.wantPoints)
+			}
+		})
+	}
+}
+
+func TestLaggedCorrelationBeyondMaxLag(t *testing.T) {
+	candidate := series(time.Minute, irregular(40)...)
+	r, lag, _, ok := laggedCorrelation(shifted(candidate, time.Minute, 8), candidate, time.Minute, 5, CorrelationPearson)
+	if !ok || math.Abs(r) > 0.9 {
+		t.Errorf("correlation shifted beyond the max lag = %v at lag %d, want a weak one", r, lag)
+	}
+}
+
+func TestLaggedCorrelationPearsonNonLinear(t *testing.T) {
+	candidate := series(time.Minute, irregular(40)...)
+	target := mapped(candidate, func(v float64) float64 { return math.Exp(v / 10) })
+	r, lag, _, ok := laggedCorrelation(target, candidate, time.Minute, 0, CorrelationPearson)
+	if !ok || lag != 0 || r >= 0.99 {
+		t.Errorf("pearson of an exponential relation = %v at lag %d, want a weaker linear correlation", r, lag)
+	}
+}
+
+func TestLagOrder(t *testing.T) {
+	if got, want := lagOrder(2), []int{0, 1, -1, 2, -2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lagOrder(2) = %v, want %v", got, want)
+	}
+	if got, want := lagOrder(0), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lagOrder(0) = %v, want %v", got, want)
+	}
+}
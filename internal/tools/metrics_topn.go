@@ -0,0 +1,244 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultTopN = 10
+	maxTopN     = 100
+)
+
+// metricUnits gives the unit of a metric by the suffix of its name, and the scale turning
+// the stored value into that unit, e.g. the SLA is stored in hundredths of a percent.
+var metricUnits = []struct {
+	suffix string
+	unit   string
+	scale  float64
+}{
+	{"_cpm", "calls/min", 1},
+	{"_rpm", "requests/min", 1},
+	{"_sla", "%", 0.01},
+	{"_apdex", "score", 0.0001},
+	{"_resp_time", "ms", 1},
+	{"_latency", "ms", 1},
+	{"_duration", "ms", 1},
+	{"_percentage", "%", 1},
+	{"_percent", "%", 1},
+	{"_bytes", "bytes", 1},
+}
+
+type TopNRequest struct {
+	TimeRange
+	ServiceSelector
+	Metric string `json:"metric"`
+	Scope  string `json:"scope"`
+	TopN   int    `json:"top_n"`
+	Order  string `json:"order"`
+}
+
+// TopNRanking ranks the entities of a scope by the average value of a metric over the time window.
+type TopNRanking struct {
+	Metric        string         `json:"metric"`
+	Scope         api.Scope      `json:"scope"`
+	ParentService string         `json:"parent_service,omitempty"`
+	Order         api.Order      `json:"order"`
+	Unit          string         `json:"unit,omitempty"`
+	Entities      []RankedEntity `json:"entities"`
+}
+
+// RankedEntity is an entity of a ranking, the value being in the unit of the ranking.
+type RankedEntity struct {
+	Rank    int      `json:"rank"`
+	Name    string   `json:"name"`
+	Service string   `json:"service,omitempty"`
+	Value   *float64 `json:"value"`
+}
+
+func topN(ctx context.Context, req TopNRequest) (*TopNRanking, error) {
+	if req.Metric == "" {
+		return nil, fmt.Errorf("metric is required")
+	}
+	scope, err := topNScope(req.Metric, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	order := api.OrderDes
+	if req.Order != "" {
+		order = api.Order(strings.ToUpper(req.Order))
+		if !order.IsValid() {
+			return nil, fmt.Errorf("invalid order %q, expected DES or ASC", req.Order)
+		}
+	}
+	n := min(req.TopN, maxTopN)
+	if n <= 0 {
+		n = defaultTopN
+	}
+	duration, err := req.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := api.TopNCondition{Name: req.Metric, Scope: &scope, TopN: n, Order: order}
+	ranking := &TopNRanking{Metric: req.Metric, Scope: scope, Order: order}
+	if req.ServiceID != "" {
+		name, normal, err := parseServiceID(req.ServiceID)
+		if err != nil {
+			return nil, err
+		}
+		condition.ParentService, condition.Normal = &name, &normal
+		ranking.ParentService = name
+	}
+
+	entities, err := topNByMQE(ctx, condition, duration)
+	if err != nil {
+		if !mqeUnsupported(err) {
+			return nil, err
+		}
+		// OAP without MQE or its top_n function still sorts metrics
+		var sortErr error
+		if entities, sortErr = topNBySortMetrics(ctx, condition, duration); sortErr != nil {
+			return nil, errors.Join(err, sortErr)
+		}
+	}
+
+	unit, scale := metricUnit(req.Metric)
+	ranking.Unit = unit
+	for i := range entities {
+		entities[i].Rank = i + 1
+		if entities[i].Value != nil && scale != 1 {
+			value := *entities[i].Value * scale
+			entities[i].Value = &value
+		}
+	}
+	ranking.Entities = entities
+	return ranking, nil
+}
+
+// topNScopes are the scopes whose entities can be ranked.
+var topNScopes = []api.Scope{api.ScopeService, api.ScopeServiceInstance, api.ScopeEndpoint}
+
+// topNScope returns the scope of the ranking, inferred from the metric name when not given.
+func topNScope(metric, name string) (api.Scope, error) {
+	if name == "" {
+		scope := scopeFromName(metric)
+		switch {
+		case scope == "":
+			return api.ScopeService, nil
+		case slices.Contains(topNScopes, scope):
+			return scope, nil
+		default:
+			return "", fmt.Errorf("metric %v is of scope %v, only Service, ServiceInstance and Endpoint metrics can be ranked", metric, scope)
+		}
+	}
+	for _, scope := range topNScopes {
+		if strings.EqualFold(string(scope), name) {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("invalid scope %q, expected Service, ServiceInstance or Endpoint", name)
+}
+
+func topNByMQE(ctx context.Context, condition api.TopNCondition, duration api.Duration) ([]RankedEntity, error) {
+	entity := &api.Entity{Scope: condition.Scope, ServiceName: condition.ParentService, Normal: condition.Normal}
+	expression := fmt.Sprintf("top_n(%s, %d, %s)", condition.Name, condition.TopN, strings.ToLower(string(condition.Order)))
+	result, err := execMQE(ctx, expression, entity, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []RankedEntity{}
+	for _, series := range result.Results {
+		if series == nil {
+			continue
+		}
+		for _, value := range series.Values {
+			if value != nil {
+				item := mqeItem(value)
+				entity := RankedEntity{Name: item.Name, Value: item.Value}
+				if value.Owner != nil && *condition.Scope != api.ScopeService {
+					entity.Service = stringValue(value.Owner.ServiceName)
+				}
+				entities = append(entities, entity)
+			}
+		}
+	}
+	return entities, nil
+}
+
+func topNBySortMetrics(ctx context.Context, condition api.TopNCondition, duration api.Duration) ([]RankedEntity, error) {
+	records, err := metrics.SortMetrics(ctx, condition, duration)
+	if err != nil {
+		return nil, fmt.Errorf("sort metrics %v failed: %w", condition.Name, err)
+	}
+
+	entities := make([]RankedEntity, 0, len(records))
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+		entity := RankedEntity{Name: record.Name}
+		if record.Value != nil {
+			if value, err := strconv.ParseFloat(*record.Value, 64); err == nil {
+				entity.Value = &value
+			}
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// metricUnit returns the unit of a metric and the scale of its values, inferred from its name.
+func metricUnit(metric string) (unit string, scale float64) {
+	for _, u := range metricUnits {
+		if strings.HasSuffix(metric, u.suffix) {
+			return u.unit, u.scale
+		}
+	}
+	return "", 1
+}
+
+var TopNTool = NewTool[TopNRequest, *TopNRanking](
+	"top_n",
+	"Rank the services, instances or endpoints by a metric over the time window, e.g. the slowest endpoints "+
+		"by endpoint_resp_time or the busiest services by service_cpm, with values in the unit of the metric",
+	topN,
+	mcp.WithTitleAnnotation("Top N"),
+	mcp.WithString("metric", mcp.Required(),
+		mcp.Description("The metric to rank by, e.g. service_resp_time, service_cpm, service_sla, endpoint_resp_time, service_instance_cpm")),
+	mcp.WithString("scope", mcp.Enum(string(api.ScopeService), string(api.ScopeServiceInstance), string(api.ScopeEndpoint)),
+		mcp.Description("The scope of the ranked entities, inferred from the metric name when omitted")),
+	mcp.WithNumber("top_n", mcp.Description("Number of entities, defaults to 10, at most 100")),
+	mcp.WithString("order", mcp.Enum(string(api.OrderDes), string(api.OrderAsc)),
+		mcp.Description("DES ranks the highest values first, ASC the lowest ones. Defaults to DES")),
+	mcp.WithString("service_id", mcp.Description("The ID of the parent service of the ranked instances or endpoints")),
+	mcp.WithString("service_name",
+		mcp.Description("The name of the parent service of the ranked instances or endpoints, partial names are resolved")),
+	WithTimeRange(),
+)
@@ -0,0 +1,310 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Transformations of metrics results.
+const (
+	TransformNone        = "none"
+	TransformAuto        = "auto"
+	TransformPercentiles = "percentiles"
+	TransformHeatmap     = "heatmap"
+)
+
+const (
+	// percentileLabel is the label of the percentile rank, e.g. service_percentile{p='50,99'}.
+	percentileLabel = "p"
+	// heatmapBucketLabel is the label of the lower bound of a heatmap bucket, e.g. service_heatmap.
+	heatmapBucketLabel = "le"
+	// minModeShare is the share of the samples a local maximum needs to be a mode.
+	minModeShare = 0.05
+	maxModes     = 3
+	// tailFactor sets where the tail starts, at this factor times the upper bound of the median bucket.
+	tailFactor = 2
+)
+
+// ResultTransform is embedded in the requests of metrics tools that can transform their results.
+type ResultTransform struct {
+	Transform string `json:"transform,omitempty"`
+}
+
+// PercentileTable pivots the percentile series into one column per percentile rank,
+// each row being a time bucket from the start of the result, or the single value.
+type PercentileTable struct {
+	Labels  map[string]string `json:"labels,omitempty"`
+	Columns []string          `json:"columns"`
+	Rows    [][]*float64      `json:"rows"`
+}
+
+// HeatmapSummary summarizes the heatmap buckets over the whole window into a distribution.
+// The percentiles are the ranges of the buckets they fall in, and the tail mass is the share
+// of the samples in the buckets starting at twice the upper bound of the median bucket or more.
+type HeatmapSummary struct {
+	Labels   map[string]string `json:"labels,omitempty"`
+	Total    float64           `json:"total"`
+	Buckets  []HeatmapBucket   `json:"buckets"`
+	Modes    []string          `json:"modes"`
+	P50      string            `json:"p50,omitempty"`
+	P90      string            `json:"p90,omitempty"`
+	P99      string            `json:"p99,omitempty"`
+	TailMass float64           `json:"tail_mass"`
+}
+
+// HeatmapBucket is a non-empty bucket of a heatmap, with the number and the share of its samples.
+type HeatmapBucket struct {
+	Range string  `json:"range"`
+	Count float64 `json:"count"`
+	Share float64 `json:"share"`
+}
+
+type heatmapBucket struct {
+	lower, upper float64
+	label        string
+	count        float64
+}
+
+// transformResult replaces the series of the result by their percentile table or heatmap summary.
+// The auto transformation picks one by the labels of the series, leaving other results as is.
+func transformResult(result *MQEResult, transform string) error {
+	switch transform {
+	case "", TransformNone:
+		return nil
+	case TransformAuto:
+		switch {
+		case hasLabel(result.Series, percentileLabel):
+			transform = TransformPercentiles
+		case hasLabel(result.Series, heatmapBucketLabel):
+			transform = TransformHeatmap
+		default:
+			return nil
+		}
+	case TransformPercentiles, TransformHeatmap:
+	default:
+		return fmt.Errorf("invalid transform %q", transform)
+	}
+
+	if transform == TransformPercentiles {
+		if !hasLabel(result.Series, percentileLabel) {
+			return fmt.Errorf("no series of expression %v has the percentile label %q", result.Expression, percentileLabel)
+		}
+		result.Percentiles = pivotPercentiles(result.Series)
+	} else {
+		if !hasLabel(result.Series, heatmapBucketLabel) {
+			return fmt.Errorf("no series of expression %v has the heatmap bucket label %q", result.Expression, heatmapBucketLabel)
+		}
+		result.Heatmap = summarizeHeatmaps(result.Series)
+	}
+	result.Series = nil
+	return nil
+}
+
+func hasLabel(series []MQESeries, key string) bool {
+	for _, s := range series {
+		if _, ok := s.Labels[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// groupByOtherLabels groups the series by their labels other than the key, in order of appearance.
+func groupByOtherLabels(series []MQESeries, key string) (groups [][]MQESeries, labels []map[string]string) {
+	index := make(map[string]int)
+	for _, s := range series {
+		other := make(map[string]string, len(s.Labels))
+		var parts []string
+		for k, v := range s.Labels {
+			if k != key {
+				other[k] = v
+				parts = append(parts, k+"="+v)
+			}
+		}
+		sort.Strings(parts)
+		groupKey := strings.Join(parts, ",")
+		i, ok := index[groupKey]
+		if !ok {
+			i = len(groups)
+			index[groupKey] = i
+			groups = append(groups, nil)
+			if len(other) == 0 {
+				other = nil
+			}
+			labels = append(labels, other)
+		}
+		groups[i] = append(groups[i], s)
+	}
+	return groups, labels
+}
+
+// seriesValues returns the values of a time series, or its single value as one value.
+func seriesValues(s MQESeries) []*float64 {
+	if s.Values == nil && s.Value != nil {
+		return []*float64{s.Value}
+	}
+	return s.Values
+}
+
+func pivotPercentiles(series []MQESeries) []PercentileTable {
+	groups, labels := groupByOtherLabels(series, percentileLabel)
+	tables := make([]PercentileTable, 0, len(groups))
+	for g, group := range groups {
+		rank := func(s MQESeries) float64 {
+			v, _ := strconv.ParseFloat(s.Labels[percentileLabel], 64)
+			return v
+		}
+		sort.SliceStable(group, func(i, j int) bool { return rank(group[i]) < rank(group[j]) })
+
+		table := PercentileTable{Labels: labels[g], Columns: make([]string, len(group))}
+		rows := 0
+		for i, s := range group {
+			table.Columns[i] = "p" + s.Labels[percentileLabel]
+			rows = max(rows, len(seriesValues(s)))
+		}
+		table.Rows = make([][]*float64, rows)
+		for r := range table.Rows {
+			table.Rows[r] = make([]*float64, len(group))
+			for c, s := range group {
+				if values := seriesValues(s); r < len(values) {
+					table.Rows[r][c] = values[r]
+				}
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+func summarizeHeatmaps(series []MQESeries) []HeatmapSummary {
+	groups, labels := groupByOtherLabels(series, heatmapBucketLabel)
+	summaries := make([]HeatmapSummary, 0, len(groups))
+	for g, group := range groups {
+		buckets := make([]heatmapBucket, 0, len(group))
+		for _, s := range group {
+			bucket := heatmapBucket{label: s.Labels[heatmapBucketLabel]}
+			lower, err := strconv.ParseFloat(bucket.label, 64)
+			if err != nil {
+				continue
+			}
+			bucket.lower = lower
+			for _, v := range seriesValues(s) {
+				if v != nil {
+					bucket.count += *v
+				}
+			}
+			buckets = append(buckets, bucket)
+		}
+		summary := summarizeHeatmap(buckets)
+		summary.Labels = labels[g]
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// summarizeHeatmap summarizes buckets given by their lower bound, each bucket ending where the next one starts.
+func summarizeHeatmap(buckets []heatmapBucket) HeatmapSummary {
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].lower < buckets[j].lower })
+	summary := HeatmapSummary{Buckets: []HeatmapBucket{}, Modes: []string{}}
+	for i := range buckets {
+		buckets[i].upper = math.Inf(1)
+		if i+1 < len(buckets) {
+			buckets[i].upper = buckets[i+1].lower
+		}
+		summary.Total += buckets[i].count
+	}
+	if summary.Total == 0 {
+		return summary
+	}
+
+	var modes []heatmapBucket
+	var cumulative, tailStart float64
+	tailStart = math.Inf(1)
+	for i, bucket := range buckets {
+		if bucket.count == 0 {
+			continue
+		}
+		share := bucket.count / summary.Total
+		summary.Buckets = append(summary.Buckets, HeatmapBucket{
+			Range: bucketRange(bucket),
+			Count: bucket.count,
+			Share: roundFloat(share, 4),
+		})
+
+		previous, next := 0.0, 0.0
+		if i > 0 {
+			previous = buckets[i-1].count
+		}
+		if i+1 < len(buckets) {
+			next = buckets[i+1].count
+		}
+		if bucket.count > previous && bucket.count >= next && share >= minModeShare {
+			modes = append(modes, bucket)
+		}
+
+		before := cumulative
+		cumulative += bucket.count
+		for _, p := range []struct {
+			rank   float64
+			target *string
+		}{{0.5, &summary.P50}, {0.9, &summary.P90}, {0.99, &summary.P99}} {
+			if before < p.rank*summary.Total && cumulative >= p.rank*summary.Total {
+				*p.target = bucketRange(bucket)
+				if p.rank == 0.5 {
+					tailStart = tailFactor * bucket.upper
+				}
+			}
+		}
+	}
+
+	var tail float64
+	for _, bucket := range buckets {
+		if bucket.lower >= tailStart {
+			tail += bucket.count
+		}
+	}
+	summary.TailMass = roundFloat(tail/summary.Total, 4)
+
+	sort.SliceStable(modes, func(i, j int) bool { return modes[i].count > modes[j].count })
+	for _, mode := range modes[:min(len(modes), maxModes)] {
+		summary.Modes = append(summary.Modes, bucketRange(mode))
+	}
+	return summary
+}
+
+func bucketRange(bucket heatmapBucket) string {
+	if math.IsInf(bucket.upper, 1) {
+		return bucket.label + "+"
+	}
+	return bucket.label + "-" + strconv.FormatFloat(bucket.upper, 'f', -1, 64)
+}
+
+// WithResultTransform adds the argument bound to ResultTransform to a tool.
+func WithResultTransform() mcp.ToolOption {
+	return mcp.WithString("transform", mcp.Enum(TransformNone, TransformAuto, TransformPercentiles, TransformHeatmap),
+		mcp.Description("Transformation of the result: percentiles pivots the percentile series, e.g. of service_percentile, "+
+			"into one column per rank; heatmap summarizes the buckets of a heatmap, e.g. service_heatmap, into a distribution "+
+			"with its modes, percentiles and tail mass; auto picks one by the labels of the result. Defaults to none"))
+}
@@ -0,0 +1,225 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// values returns pointers to the values, nil standing for the NaN of a missing value.
+func values(vs ...float64) []*float64 {
+	result := make([]*float64, len(vs))
+	for i := range vs {
+		if !math.IsNaN(vs[i]) {
+			result[i] = &vs[i]
+		}
+	}
+	return result
+}
+
+// rowValues dereferences the rows of a percentile table, -1 standing for a missing value.
+func rowValues(rows [][]*float64) [][]float64 {
+	result := make([][]float64, len(rows))
+	for r, row := range rows {
+		result[r] = make([]float64, len(row))
+		for c, v := range row {
+			result[r][c] = -1
+			if v != nil {
+				result[r][c] = *v
+			}
+		}
+	}
+	return result
+}
+
+func TestPivotPercentiles(t *testing.T) {
+	single := 7.0
+	tests := []struct {
+		name       string
+		series     []MQESeries
+		wantLabels []map[string]string
+		wantCols   [][]string
+		wantRows   [][][]float64
+	}{
+		{
+			name: "sorted by rank",
+			series: []MQESeries{
+				{Labels: map[string]string{"p": "99"}, Values: values(30, 40)},
+				{Labels: map[string]string{"p": "50"}, Values: values(10, 20)},
+				{Labels: map[string]string{"p": "90"}, Values: values(25, math.NaN())},
+			},
+			wantLabels: []map[string]string{nil},
+			wantCols:   [][]string{{"p50", "p90", "p99"}},
+			wantRows:   [][][]float64{{{10, 25, 30}, {20, -1, 40}}},
+		},
+		{
+			name: "grouped by other labels",
+			series: []MQESeries{
+				{Labels: map[string]string{"p": "50", "service": "a"}, Values: values(1)},
+				{Labels: map[string]string{"p": "50", "service": "b"}, Values: values(2)},
+				{Labels: map[string]string{"p": "99", "service": "a"}, Values: values(3)},
+			},
+			wantLabels: []map[string]string{{"service": "a"}, {"service": "b"}},
+			wantCols:   [][]string{{"p50", "p99"}, {"p50"}},
+			wantRows:   [][][]float64{{{1, 3}}, {{2}}},
+		},
+		{
+			name: "uneven series",
+			series: []MQESeries{
+				{Labels: map[string]string{"p": "50"}, Value: &single},
+				{Labels: map[string]string{"p": "99"}, Values: values(8, 9)},
+			},
+			wantLabels: []map[string]string{nil},
+			wantCols:   [][]string{{"p50", "p99"}},
+			wantRows:   [][][]float64{{{7, 8}, {-1, 9}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := pivotPercentiles(tt.series)
+			if len(tables) != len(tt.wantCols) {
+				t.Fatalf("tables = %d, want %d", len(tables), len(tt.wantCols))
+			}
+			for i, table := range tables {
+				if !reflect.DeepEqual(table.Labels, tt.wantLabels[i]) {
+					t.Errorf("labels of table %d = %v, want %v", i, table.Labels, tt.wantLabels[i])
+				}
+				if !reflect.DeepEqual(table.Columns, tt.wantCols[i]) {
+					t.Errorf("columns of table %d = %v, want %v", i, table.Columns, tt.wantCols[i])
+				}
+				if rows := rowValues(table.Rows); !reflect.DeepEqual(rows, tt.wantRows[i]) {
+					t.Errorf("rows of table %d = %v, want %v", i, rows, tt.wantRows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeHeatmap(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets map[string]float64
+		want    HeatmapSummary
+	}{
+		{
+			name:    "long tail",
+			buckets: map[string]float64{"0": 10, "100": 60, "200": 20, "500": 5, "1000": 5},
+			want: HeatmapSummary{
+				Total: 100,
+				Buckets: []HeatmapBucket{
+					{Range: "0-100", Count: 10, Share: 0.1},
+					{Range: "100-200", Count: 60, Share: 0.6},
+					{Range: "200-500", Count: 20, Share: 0.2},
+					{Range: "500-1000", Count: 5, Share: 0.05},
+					{Range: "1000+", Count: 5, Share: 0.05},
+				},
+				Modes:    []string{"100-200"},
+				P50:      "100-200",
+				P90:      "200-500",
+				P99:      "1000+",
+				TailMass: 0.1,
+			},
+		},
+		{
+			name:    "bimodal",
+			buckets: map[string]float64{"0": 40, "100": 5, "200": 10, "500": 45},
+			want: HeatmapSummary{
+				Total: 100,
+				Buckets: []HeatmapBucket{
+					{Range: "0-100", Count: 40, Share: 0.4},
+					{Range: "100-200", Count: 5, Share: 0.05},
+					{Range: "200-500", Count: 10, Share: 0.1},
+					{Range: "500+", Count: 45, Share: 0.45},
+				},
+				Modes: []string{"500+", "0-100"},
+				P50:   "200-500",
+				P90:   "500+",
+				P99:   "500+",
+			},
+		},
+		{
+			name:    "empty buckets",
+			buckets: map[string]float64{"0": 0, "100": 4, "200": 0},
+			want: HeatmapSummary{
+				Total:   4,
+				Buckets: []HeatmapBucket{{Range: "100-200", Count: 4, Share: 1}},
+				Modes:   []string{"100-200"},
+				P50:     "100-200",
+				P90:     "100-200",
+				P99:     "100-200",
+			},
+		},
+		{
+			name:    "no samples",
+			buckets: map[string]float64{"0": 0, "100": 0},
+			want:    HeatmapSummary{Buckets: []HeatmapBucket{}, Modes: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var series []MQESeries
+			for le, count := range tt.buckets {
+				series = append(series, MQESeries{Labels: map[string]string{"le": le}, Values: values(count/2, count/2)})
+			}
+			summaries := summarizeHeatmaps(series)
+			if len(summaries) != 1 {
+				t.Fatalf("summaries = %d, want 1", len(summaries))
+			}
+			if !reflect.DeepEqual(summaries[0], tt.want) {
+				t.Errorf("summary = %+v, want %+v", summaries[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformResult(t *testing.T) {
+	percentiles := []MQESeries{{Labels: map[string]string{"p": "50"}, Values: values(1)}}
+	heatmap := []MQESeries{{Labels: map[string]string{"le": "0"}, Values: values(1)}}
+	tests := []struct {
+		name            string
+		series          []MQESeries
+		transform       string
+		wantPercentiles bool
+		wantHeatmap     bool
+		wantErr         bool
+	}{
+		{name: "none", series: percentiles, transform: TransformNone},
+		{name: "auto percentiles", series: percentiles, transform: TransformAuto, wantPercentiles: true},
+		{name: "auto heatmap", series: heatmap, transform: TransformAuto, wantHeatmap: true},
+		{name: "auto plain", series: []MQESeries{{Values: values(1)}}, transform: TransformAuto},
+		{name: "heatmap without buckets", series: percentiles, transform: TransformHeatmap, wantErr: true},
+		{name: "percentiles without ranks", series: heatmap, transform: TransformPercentiles, wantErr: true},
+		{name: "unknown", series: heatmap, transform: "histogram", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &MQEResult{Expression: "e", Series: tt.series}
+			if err := transformResult(result, tt.transform); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if (result.Percentiles != nil) != tt.wantPercentiles || (result.Heatmap != nil) != tt.wantHeatmap {
+				t.Errorf("percentiles = %v, heatmap = %v", result.Percentiles, result.Heatmap)
+			}
+			if transformed := tt.wantPercentiles || tt.wantHeatmap; (result.Series == nil) != transformed {
+				t.Errorf("series = %v, want them replaced %v", result.Series, transformed)
+			}
+		})
+	}
+}
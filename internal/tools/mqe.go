@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/metrics"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// execMQE executes a metrics query expression, turning the error reported in the result into an error.
+func execMQE(ctx context.Context, expression string, entity *api.Entity, duration api.Duration) (*api.ExpressionResult, error) {
+	result, err := metrics.Execute(ctx, expression, entity, duration)
+	if err != nil {
+		return nil, fmt.Errorf("execute expression %v failed: %w", expression, err)
+	}
+	if result.Error != nil && *result.Error != "" {
+		return nil, &mqeError{expression: expression, message: *result.Error}
+	}
+	return &result, nil
+}
+
+// mqeError is the error OAP reports about an expression it cannot execute, e.g. for an unknown
+// metric or function, as opposed to the failures of the query itself such as timeouts.
+type mqeError struct {
+	expression string
+	message    string
+}
+
+func (e *mqeError) Error() string {
+	return fmt.Sprintf("execute expression %v failed: %s", e.expression, e.message)
+}
+
+// mqeUnsupported tells whether the error is OAP rejecting the expression, or not knowing MQE at all,
+// in which case the GraphQL schema has no execExpression field.
+func mqeUnsupported(err error) bool {
+	var expressionErr *mqeError
+	return errors.As(err, &expressionErr) || strings.Contains(err.Error(), "execExpression")
+}
+
+// singleMQEValue executes an expression expected to return a single value, returning false if it has no value.
+func singleMQEValue(ctx context.Context, expression string, entity *api.Entity, duration api.Duration) (float64, bool, error) {
+	result, err := execMQE(ctx, expression, entity, duration)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, series := range result.Results {
+		for _, value := range series.Values {
+			if v, ok := parseMQEValue(value); ok {
+				return v, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// parseMQEValue parses the value of an MQE result, empty values having no value.
+func parseMQEValue(value *api.MQEValue) (float64, bool) {
+	if value == nil || value.Value == nil || *value.Value == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(*value.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
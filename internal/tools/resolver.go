@@ -0,0 +1,427 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/apache/skywalking-cli/pkg/contextkey"
+	"github.com/apache/skywalking-cli/pkg/graphql/metadata"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	// metadataTTL is how long the services, instances and endpoints of an OAP are cached.
+	metadataTTL = 5 * time.Minute
+	// maxCachedEndpoints bounds the endpoints loaded per service, the name of an endpoint
+	// beyond it must be given exactly.
+	maxCachedEndpoints = 1000
+	// maxCacheEntries bounds the lists cached over all OAPs and services, the oldest being evicted.
+	maxCacheEntries = 1000
+	maxCandidates   = 10
+)
+
+// Scores of a name matching the input, the higher the better.
+const (
+	scoreExact       = 100
+	scoreIgnoreCase  = 90
+	scoreAllTokens   = 70
+	scoreSubstring   = 50
+	scoreTokenPrefix = 40
+)
+
+// fillerWords are dropped from the input, e.g. "the payment service" looks for "payment".
+var fillerWords = map[string]bool{
+	"the": true, "a": true, "an": true, "of": true, "service": true, "instance": true, "endpoint": true,
+}
+
+// AmbiguousNameError is returned when an input matches several names equally well.
+type AmbiguousNameError struct {
+	Kind       string
+	Input      string
+	Candidates []string
+}
+
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous, candidates: %s", e.Kind, e.Input, strings.Join(e.Candidates, ", "))
+}
+
+// argumentResolver is implemented by requests whose arguments accept human-friendly names,
+// ConvertTool calls it right after binding the arguments.
+type argumentResolver interface {
+	resolveArguments(ctx context.Context) error
+}
+
+// ServiceSelector is embedded in the requests that select a service by its ID or by its name.
+type ServiceSelector struct {
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name"`
+}
+
+// resolveArguments replaces a fuzzy service name by the exact one and fills the service ID.
+func (s *ServiceSelector) resolveArguments(ctx context.Context) error {
+	if s.ServiceID != "" || s.ServiceName == "" {
+		return nil
+	}
+	service, err := resolveServiceName(ctx, s.ServiceName)
+	if err != nil {
+		return err
+	}
+	s.ServiceID, s.ServiceName = service.ID, service.Name
+	return nil
+}
+
+// EntitySelector is embedded in the requests that select a service, and optionally one of its instances
+// and one of its endpoints, by their IDs or by their names.
+type EntitySelector struct {
+	ServiceSelector
+	ServiceInstanceID   string `json:"service_instance_id"`
+	ServiceInstanceName string `json:"service_instance_name"`
+	EndpointID          string `json:"endpoint_id"`
+	EndpointName        string `json:"endpoint_name"`
+}
+
+// resolveArguments resolves the service, instance and endpoint names to the exact ones.
+func (s *EntitySelector) resolveArguments(ctx context.Context) error {
+	if err := s.ServiceSelector.resolveArguments(ctx); err != nil {
+		return err
+	}
+	if s.ServiceID == "" {
+		return nil
+	}
+
+	var err error
+	if s.ServiceInstanceID == "" && s.ServiceInstanceName != "" {
+		if s.ServiceInstanceName, err = resolveInstanceName(ctx, s.ServiceID, s.ServiceInstanceName); err != nil {
+			return err
+		}
+	}
+	if s.EndpointID == "" && s.EndpointName != "" {
+		if s.EndpointName, err = resolveEndpointName(ctx, s.ServiceID, s.EndpointName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entityIDs returns the IDs of the selected service, instance and endpoint, nil when not selected, deriving
+// them from the names when no IDs are given. Names of instances and endpoints are only meaningful within
+// a service, which must then be given.
+func (s *EntitySelector) entityIDs(ctx context.Context) (serviceID, instanceID, endpointID *string, err error) {
+	service, err := resolveServiceID(ctx, s.ServiceID, s.ServiceName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	instance, endpoint := s.ServiceInstanceID, s.EndpointID
+	if instance == "" && s.ServiceInstanceName != "" {
+		if service == "" {
+			return nil, nil, nil, fmt.Errorf("service_instance_name requires service_id or service_name")
+		}
+		instance = buildInstanceID(service, s.ServiceInstanceName)
+	}
+	if endpoint == "" && s.EndpointName != "" {
+		if service == "" {
+			return nil, nil, nil, fmt.Errorf("endpoint_name requires service_id or service_name")
+		}
+		endpoint = buildEndpointID(service, s.EndpointName)
+	}
+	return optionalString(service), optionalString(instance), optionalString(endpoint), nil
+}
+
+// optionalString returns nil for an empty string, which the query then leaves out.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// parseTags parses tags in the form of key=value into the tags of a query.
+func parseTags[T any](tags []string, newTag func(key string, value *string) T) ([]T, error) {
+	parsed := make([]T, 0, len(tags))
+	for _, tag := range tags {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q, expected key=value", tag)
+		}
+		parsed = append(parsed, newTag(key, &value))
+	}
+	return parsed, nil
+}
+
+type cacheEntry struct {
+	loadedAt time.Time
+	value    any
+}
+
+// metadataCache caches the metadata of each OAP, keyed by the OAP URL.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+var entityCache = &metadataCache{entries: make(map[string]cacheEntry)}
+
+// cached returns the value cached under the key for the OAP of the context, loading it when missing or expired.
+func cached[T any](ctx context.Context, key string, load func() (T, error)) (T, error) {
+	baseURL, _ := ctx.Value(contextkey.BaseURL{}).(string)
+	key = baseURL + "|" + key
+
+	entityCache.mu.Lock()
+	entry, ok := entityCache.entries[key]
+	entityCache.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < metadataTTL {
+		return entry.value.(T), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	entityCache.store(key, value)
+	return value, nil
+}
+
+// store caches the value, evicting the expired entries and then the oldest one when the cache is full.
+func (c *metadataCache) store(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	oldestKey, oldest := "", now
+	for k, entry := range c.entries {
+		if now.Sub(entry.loadedAt) >= metadataTTL {
+			delete(c.entries, k)
+		} else if entry.loadedAt.Before(oldest) {
+			oldestKey, oldest = k, entry.loadedAt
+		}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		delete(c.entries, oldestKey)
+	}
+	c.entries[key] = cacheEntry{loadedAt: now, value: value}
+}
+
+func cachedServices(ctx context.Context) ([]api.Service, error) {
+	return cached(ctx, "services", func() ([]api.Service, error) {
+		return listServices(ctx, ListServicesRequest{})
+	})
+}
+
+func cachedInstances(ctx context.Context, serviceID string) ([]api.ServiceInstance, error) {
+	return cached(ctx, "instances|"+serviceID, func() ([]api.ServiceInstance, error) {
+		duration, err := TimeRange{}.toDuration(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.Instances(ctx, serviceID, duration)
+	})
+}
+
+func cachedEndpoints(ctx context.Context, serviceID string) ([]api.Endpoint, error) {
+	return cached(ctx, "endpoints|"+serviceID, func() ([]api.Endpoint, error) {
+		duration, err := TimeRange{}.toDuration(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.SearchEndpoints(ctx, serviceID, "", maxCachedEndpoints, &duration)
+	})
+}
+
+// resolveServiceName finds the service best matching a possibly partial or fuzzy name.
+func resolveServiceName(ctx context.Context, input string) (api.Service, error) {
+	services, err := cachedServices(ctx)
+	if err != nil {
+		return api.Service{}, fmt.Errorf("load services failed: %w", err)
+	}
+	index, err := bestMatch("service", input, len(services), func(i int) []string {
+		return []string{services[i].Name, services[i].ShortName}
+	})
+	if err != nil {
+		return api.Service{}, err
+	}
+	return services[index], nil
+}
+
+// resolveInstanceName finds the instance of the service best matching a possibly partial or fuzzy name.
+func resolveInstanceName(ctx context.Context, serviceID, input string) (string, error) {
+	instances, err := cachedInstances(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("load instances failed: %w", err)
+	}
+	names := make([]string, len(instances))
+	for i, instance := range instances {
+		names[i] = instance.Name
+	}
+	return resolveRecentName("instance", input, names)
+}
+
+// resolveEndpointName finds the endpoint of the service best matching a possibly partial or fuzzy name.
+func resolveEndpointName(ctx context.Context, serviceID, input string) (string, error) {
+	endpoints, err := cachedEndpoints(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("load endpoints failed: %w", err)
+	}
+	names := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		names[i] = endpoint.Name
+	}
+	return resolveRecentName("endpoint", input, names)
+}
+
+// resolveRecentName resolves the name of an instance or endpoint among the names loaded, which are only
+// those of a recent time window and a bounded count. An input no name matches is kept, ambiguous inputs fail.
+//
+// Inputs written as exact names are only matched exactly, ignoring the case, and kept otherwise: the near
+// match of an exact name is another entity, e.g. the live pod-abc-12 for the crashed pod-abc-1, or
+// GET:/api/users/{id} for GET:/api/users.
+func resolveRecentName(kind, input string, names []string) (string, error) {
+	if isExactName(input) {
+		var folded []string
+		for _, name := range names {
+			if name == input {
+				return name, nil
+			}
+			if strings.EqualFold(name, input) {
+				folded = append(folded, name)
+			}
+		}
+		if len(folded) == 1 {
+			return folded[0], nil
+		}
+		return input, nil
+	}
+
+	index, err := bestMatch(kind, input, len(names), func(i int) []string {
+		return []string{names[i]}
+	})
+	var ambiguous *AmbiguousNameError
+	switch {
+	case errors.As(err, &ambiguous):
+		return "", err
+	case err != nil:
+		return input, nil
+	}
+	return names[index], nil
+}
+
+// isExactName tells whether the input is written as a name copied from OAP rather than as words to look for,
+// i.e. a path, or a single word with digits or separators such as GET:/api/users or pod-abc-1.
+func isExactName(input string) bool {
+	if strings.Contains(input, "/") {
+		return true
+	}
+	return !strings.ContainsFunc(input, unicode.IsSpace) && strings.ContainsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// bestMatch scores the names of the n candidates against the input and returns the index
+// of the only best candidate, or an AmbiguousNameError listing the best candidates.
+func bestMatch(kind, input string, n int, names func(i int) []string) (int, error) {
+	best, bestScore := []int(nil), 0
+	for i := range n {
+		score := 0
+		for _, name := range names(i) {
+			score = max(score, matchScore(input, name))
+		}
+		switch {
+		case score == 0 || score < bestScore:
+		case score > bestScore:
+			best, bestScore = []int{i}, score
+		default:
+			best = append(best, i)
+		}
+	}
+
+	switch {
+	case len(best) == 0:
+		return 0, fmt.Errorf("no %s matches %q", kind, input)
+	case len(best) == 1 || bestScore == scoreExact:
+		return best[0], nil
+	}
+	candidates := make([]string, 0, min(len(best), maxCandidates))
+	for _, i := range best[:min(len(best), maxCandidates)] {
+		candidates = append(candidates, names(i)[0])
+	}
+	sort.Strings(candidates)
+	return 0, &AmbiguousNameError{Kind: kind, Input: input, Candidates: candidates}
+}
+
+// matchScore tells how well a name matches the input, 0 meaning no match.
+func matchScore(input, name string) int {
+	switch {
+	case name == "":
+		return 0
+	case input == name:
+		return scoreExact
+	case strings.EqualFold(input, name):
+		return scoreIgnoreCase
+	}
+
+	inputTokens, nameTokens := nameTokens(input, true), nameTokens(name, false)
+	if len(inputTokens) == 0 {
+		return 0
+	}
+	matched, prefixed := 0, 0
+	for _, token := range inputTokens {
+		for _, nameToken := range nameTokens {
+			if token == nameToken {
+				matched++
+				break
+			}
+			if strings.HasPrefix(nameToken, token) {
+				prefixed++
+				break
+			}
+		}
+	}
+	// fewer extra tokens in the name mean a closer match
+	extra := max(len(nameTokens)-len(inputTokens), 0)
+	switch {
+	case matched == len(inputTokens):
+		return scoreAllTokens - min(extra, scoreAllTokens-scoreSubstring-1)
+	case strings.Contains(strings.Join(nameTokens, ""), strings.Join(inputTokens, "")):
+		return scoreSubstring
+	case matched+prefixed == len(inputTokens):
+		return scoreTokenPrefix - min(extra, scoreTokenPrefix-1)
+	default:
+		return 0
+	}
+}
+
+// nameTokens splits a name into lower case alphanumeric tokens, dropping filler words from inputs.
+func nameTokens(s string, input bool) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, field := range fields {
+		if !input || !fillerWords[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
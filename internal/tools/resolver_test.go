@@ -0,0 +1,147 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		want  int
+	}{
+		{input: "payment", name: "payment", want: scoreExact},
+		{input: "Payment", name: "payment", want: scoreIgnoreCase},
+		{input: "the payment service", name: "payment", want: scoreAllTokens},
+		{input: "payment", name: "agent::payment", want: scoreAllTokens - 1},
+		{input: "payment", name: "agent::payment-v2", want: scoreAllTokens - 2},
+		{input: "paymentservice", name: "payment-service", want: scoreSubstring},
+		{input: "pay", name: "payment", want: scoreSubstring},
+		{input: "pay ord", name: "payment-order", want: scoreTokenPrefix},
+		{input: "pay ord", name: "agent::payment-order", want: scoreTokenPrefix - 1},
+		{input: "order", name: "payment", want: 0},
+		{input: "payment", name: "", want: 0},
+		{input: "the service", name: "payment", want: 0},
+	}
+	for _, tt := range tests {
+		if got := matchScore(tt.input, tt.name); got != tt.want {
+			t.Errorf("matchScore(%q, %q) = %d, want %d", tt.input, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		names         []string
+		want          string
+		wantAmbiguous []string
+		wantErr       bool
+	}{
+		{name: "exact", input: "payment", names: []string{"payment-v2", "payment"}, want: "payment"},
+		{name: "fewest extra tokens", input: "payment", names: []string{"agent::payment-v2", "agent::payment"}, want: "agent::payment"},
+		{name: "prefix", input: "ord", names: []string{"payment", "order"}, want: "order"},
+		{name: "no match", input: "inventory", names: []string{"payment", "order"}, wantErr: true},
+		{
+			name:          "ambiguous",
+			input:         "payment",
+			names:         []string{"eu::payment", "us::payment", "order"},
+			wantAmbiguous: []string{"eu::payment", "us::payment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := bestMatch("service", tt.input, len(tt.names), func(i int) []string {
+				return []string{tt.names[i]}
+			})
+			var ambiguous *AmbiguousNameError
+			switch {
+			case tt.wantAmbiguous != nil:
+				if !errors.As(err, &ambiguous) || !reflect.DeepEqual(ambiguous.Candidates, tt.wantAmbiguous) {
+					t.Errorf("bestMatch(%q) error = %v, want candidates %v", tt.input, err, tt.wantAmbiguous)
+				}
+			case tt.wantErr:
+				if err == nil || errors.As(err, &ambiguous) {
+					t.Errorf("bestMatch(%q) error = %v, want no match", tt.input, err)
+				}
+			case err != nil:
+				t.Errorf("bestMatch(%q) error = %v", tt.input, err)
+			case tt.names[index] != tt.want:
+				t.Errorf("bestMatch(%q) = %q, want %q", tt.input, tt.names[index], tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRecentName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		names   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact", input: "GET:/api/users", names: []string{"GET:/api/users/{id}", "GET:/api/users"}, want: "GET:/api/users"},
+		{name: "exact ignoring case", input: "get:/api/users", names: []string{"GET:/api/users"}, want: "GET:/api/users"},
+		{name: "endpoint not loaded", input: "GET:/api/users", names: []string{"GET:/api/users/{id}"}, want: "GET:/api/users"},
+		{name: "crashed pod", input: "pod-abc-1", names: []string{"pod-abc-12", "pod-abc-13"}, want: "pod-abc-1"},
+		{name: "path with spaces", input: "GET /api/users", names: []string{"GET /api/users/{id}"}, want: "GET /api/users"},
+		{name: "words", input: "users api", names: []string{"POST:/api/orders", "GET:/api/users"}, want: "GET:/api/users"},
+		{name: "unmatched words", input: "inventory", names: []string{"GET:/api/users"}, want: "inventory"},
+		{name: "ambiguous words", input: "users", names: []string{"GET:/users", "POST:/users"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRecentName("endpoint", tt.input, tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveRecentName(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveRecentName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataCacheStore(t *testing.T) {
+	cache := &metadataCache{entries: map[string]cacheEntry{
+		"expired": {loadedAt: time.Now().Add(-metadataTTL), value: 1},
+		"fresh":   {loadedAt: time.Now(), value: 2},
+	}}
+	cache.store("new", 3)
+	if _, ok := cache.entries["expired"]; ok || len(cache.entries) != 2 {
+		t.Errorf("entries = %v, want the expired one evicted", cache.entries)
+	}
+
+	cache = &metadataCache{entries: make(map[string]cacheEntry)}
+	for i := range maxCacheEntries - 1 {
+		cache.entries[strconv.Itoa(i)] = cacheEntry{loadedAt: time.Now()}
+	}
+	cache.entries["oldest"] = cacheEntry{loadedAt: time.Now().Add(-time.Minute)}
+	cache.store("new", 3)
+	if _, ok := cache.entries["oldest"]; ok || len(cache.entries) != maxCacheEntries {
+		t.Errorf("got %d entries, want %d without the oldest", len(cache.entries), maxCacheEntries)
+	}
+}
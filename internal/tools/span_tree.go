@@ -0,0 +1,168 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"sort"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// spanKey identifies a span within a trace.
+type spanKey struct {
+	segmentID string
+	spanID    int
+}
+
+// spanNode is a span linked to its parent and children across segments.
+type spanNode struct {
+	span     *api.Span
+	parent   *spanNode
+	children []*spanNode
+	// selfTime is the time of the span not covered by any of its children.
+	selfTime int64
+}
+
+func (n *spanNode) duration() int64 {
+	return n.span.EndTime - n.span.StartTime
+}
+
+func (n *spanNode) isError() bool {
+	return n.span.IsError != nil && *n.span.IsError
+}
+
+// spanTree is the span tree of a trace, rebuilt from the segment refs.
+type spanTree struct {
+	roots []*spanNode
+	nodes map[spanKey]*spanNode
+	// ordered holds all nodes sorted by start time.
+	ordered []*spanNode
+}
+
+// buildSpanTree links the spans of a trace into a tree. Spans whose parent
+// cannot be found in the trace become roots.
+func buildSpanTree(trace *api.Trace) *spanTree {
+	tree := &spanTree{nodes: make(map[spanKey]*spanNode, len(trace.Spans))}
+	for _, span := range trace.Spans {
+		node := &spanNode{span: span}
+		tree.nodes[spanKey{span.SegmentID, span.SpanID}] = node
+		tree.ordered = append(tree.ordered, node)
+	}
+	sort.SliceStable(tree.ordered, func(i, j int) bool {
+		return tree.ordered[i].span.StartTime < tree.ordered[j].span.StartTime
+	})
+
+	for _, node := range tree.ordered {
+		if parent := tree.parentOf(node.span); parent != nil {
+			node.parent = parent
+			parent.children = append(parent.children, node)
+		} else {
+			tree.roots = append(tree.roots, node)
+		}
+	}
+	for _, node := range tree.ordered {
+		node.selfTime = computeSelfTime(node)
+	}
+	return tree
+}
+
+// parentOf finds the parent of a span, following the segment refs for the first span of a segment.
+func (t *spanTree) parentOf(span *api.Span) *spanNode {
+	if span.ParentSpanID >= 0 {
+		return t.nodes[spanKey{span.SegmentID, span.ParentSpanID}]
+	}
+	for _, ref := range span.Refs {
+		if ref == nil {
+			continue
+		}
+		if parent, ok := t.nodes[spanKey{ref.ParentSegmentID, ref.ParentSpanID}]; ok {
+			return parent
+		}
+	}
+	return nil
+}
+
+// duration is the time between the earliest start and the latest end of all spans.
+func (t *spanTree) duration() int64 {
+	if len(t.ordered) == 0 {
+		return 0
+	}
+	start, end := t.ordered[0].span.StartTime, t.ordered[0].span.EndTime
+	for _, node := range t.ordered {
+		end = max(end, node.span.EndTime)
+	}
+	return end - start
+}
+
+// computeSelfTime subtracts the union of the children intervals, clipped to
+// the span itself, from the duration of the span.
+func computeSelfTime(node *spanNode) int64 {
+	start, end := node.span.StartTime, node.span.EndTime
+	covered, cursor := int64(0), start
+	// children are appended in start time order
+	for _, child := range node.children {
+		childStart, childEnd := max(child.span.StartTime, cursor), min(child.span.EndTime, end)
+		if childEnd > childStart {
+			covered += childEnd - childStart
+			cursor = childEnd
+		}
+	}
+	return max(end-start-covered, 0)
+}
+
+// criticalPath walks the tree backwards from the end of the longest root and
+// returns, for each span on the critical path, the time it contributes to it.
+func (t *spanTree) criticalPath() (path []*spanNode, contribution map[*spanNode]int64) {
+	if len(t.roots) == 0 {
+		return nil, nil
+	}
+	root := t.roots[0]
+	for _, candidate := range t.roots[1:] {
+		if candidate.duration() > root.duration() {
+			root = candidate
+		}
+	}
+
+	contribution = make(map[*spanNode]int64)
+	var walk func(node *spanNode, until int64)
+	walk = func(node *spanNode, until int64) {
+		path = append(path, node)
+		cursor := min(until, node.span.EndTime)
+
+		children := append([]*spanNode(nil), node.children...)
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].span.EndTime > children[j].span.EndTime
+		})
+		for _, child := range children {
+			if child.span.StartTime >= cursor || child.span.EndTime <= node.span.StartTime {
+				continue
+			}
+			childEnd := min(child.span.EndTime, cursor)
+			contribution[node] += cursor - childEnd
+			walk(child, childEnd)
+			cursor = max(child.span.StartTime, node.span.StartTime)
+		}
+		contribution[node] += cursor - node.span.StartTime
+	}
+	walk(root, root.span.EndTime)
+
+	sort.SliceStable(path, func(i, j int) bool {
+		return path[i].span.StartTime < path[j].span.StartTime
+	})
+	return path, contribution
+}
@@ -0,0 +1,180 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// testSpan builds a span of the segment of service svc named after its segment and span IDs,
+// e.g. s1/0. The refs lead to the parent of the first span of a segment.
+func testSpan(segmentID string, spanID, parentSpanID int, start, end int64, refs ...*api.Ref) *api.Span {
+	name := segmentID + "/" + strconv.Itoa(spanID)
+	return &api.Span{
+		TraceID:      "trace",
+		SegmentID:    segmentID,
+		SpanID:       spanID,
+		ParentSpanID: parentSpanID,
+		ServiceCode:  "svc",
+		EndpointName: &name,
+		Type:         "Local",
+		StartTime:    start,
+		EndTime:      end,
+		Refs:         refs,
+	}
+}
+
+func testRef(parentSegmentID string, parentSpanID int, refType api.RefType) *api.Ref {
+	return &api.Ref{TraceID: "trace", ParentSegmentID: parentSegmentID, ParentSpanID: parentSpanID, Type: refType}
+}
+
+func TestBuildSpanTree(t *testing.T) {
+	tree := buildSpanTree(&api.Trace{Spans: []*api.Span{
+		testSpan("s2", 0, -1, 20, 40, testRef("s1", 1, api.RefTypeCrossProcess)),
+		testSpan("s1", 1, 0, 10, 50),
+		testSpan("s1", 0, -1, 0, 100),
+		testSpan("s3", 0, -1, 30, 60, testRef("missing", 0, api.RefTypeCrossProcess)),
+	}})
+
+	parents := make(map[string]string)
+	for _, node := range tree.ordered {
+		if node.parent != nil {
+			parents[*node.span.EndpointName] = *node.parent.span.EndpointName
+		}
+	}
+	if want := map[string]string{"s1/1": "s1/0", "s2/0": "s1/1"}; !reflect.DeepEqual(parents, want) {
+		t.Errorf("parents = %v, want %v", parents, want)
+	}
+	if len(tree.roots) != 2 || *tree.roots[0].span.EndpointName != "s1/0" || *tree.roots[1].span.EndpointName != "s3/0" {
+		t.Errorf("roots = %v, want s1/0 and the orphan s3/0", tree.roots)
+	}
+	if got := tree.duration(); got != 100 {
+		t.Errorf("duration = %d, want 100", got)
+	}
+}
+
+func TestComputeSelfTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		children [][2]int64
+		want     int64
+	}{
+		{name: "leaf", want: 100},
+		{name: "sequential", children: [][2]int64{{10, 30}, {40, 60}}, want: 60},
+		{name: "overlapping", children: [][2]int64{{10, 50}, {30, 70}}, want: 40},
+		{name: "nested", children: [][2]int64{{10, 80}, {20, 30}}, want: 30},
+		{name: "async outliving the parent", children: [][2]int64{{80, 150}}, want: 80},
+		{name: "covering the parent", children: [][2]int64{{0, 60}, {50, 120}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := []*api.Span{testSpan("s1", 0, -1, 0, 100)}
+			for i, child := range tt.children {
+				spans = append(spans, testSpan("s1", i+1, 0, child[0], child[1]))
+			}
+			tree := buildSpanTree(&api.Trace{Spans: spans})
+			if got := tree.roots[0].selfTime; got != tt.want {
+				t.Errorf("self time = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name             string
+		spans            []*api.Span
+		wantPath         []string
+		wantContribution map[string]int64
+	}{
+		{
+			name: "sequential children",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s1", 1, 0, 10, 40),
+				testSpan("s1", 2, 0, 50, 90),
+			},
+			wantPath:         []string{"s1/0", "s1/1", "s1/2"},
+			wantContribution: map[string]int64{"s1/0": 30, "s1/1": 30, "s1/2": 40},
+		},
+		{
+			name: "overlapping children",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s1", 1, 0, 10, 60),
+				testSpan("s1", 2, 0, 20, 90),
+			},
+			wantPath:         []string{"s1/0", "s1/1", "s1/2"},
+			wantContribution: map[string]int64{"s1/0": 20, "s1/1": 10, "s1/2": 70},
+		},
+		{
+			name: "child ending before a longer sibling",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s1", 1, 0, 10, 90),
+				testSpan("s1", 2, 0, 20, 30),
+			},
+			wantPath:         []string{"s1/0", "s1/1"},
+			wantContribution: map[string]int64{"s1/0": 20, "s1/1": 80},
+		},
+		{
+			name: "async child outliving the parent",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s2", 0, -1, 50, 200, testRef("s1", 0, api.RefTypeCrossThread)),
+			},
+			wantPath:         []string{"s1/0", "s2/0"},
+			wantContribution: map[string]int64{"s1/0": 50, "s2/0": 50},
+		},
+		{
+			name: "longest root",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 50),
+				testSpan("s2", 0, -1, 10, 110, testRef("missing", 0, api.RefTypeCrossProcess)),
+			},
+			wantPath:         []string{"s2/0"},
+			wantContribution: map[string]int64{"s2/0": 100},
+		},
+		{name: "empty trace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, contribution := buildSpanTree(&api.Trace{Spans: tt.spans}).criticalPath()
+			var gotPath []string
+			gotContribution := make(map[string]int64)
+			for _, node := range path {
+				name := *node.span.EndpointName
+				gotPath = append(gotPath, name)
+				gotContribution[name] = contribution[node]
+			}
+			if !reflect.DeepEqual(gotPath, tt.wantPath) {
+				t.Errorf("path = %v, want %v", gotPath, tt.wantPath)
+			}
+			if tt.wantContribution == nil {
+				tt.wantContribution = map[string]int64{}
+			}
+			if !reflect.DeepEqual(gotContribution, tt.wantContribution) {
+				t.Errorf("contribution = %v, want %v", gotContribution, tt.wantContribution)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"sort"
+)
+
+// number is the constraint of the values the statistics helpers work on.
+type number interface {
+	~int | ~int64 | ~float64
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of the values, which must be sorted.
+func percentile[T number](sorted []T, p float64) T {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// sortedCopy returns the values sorted in ascending order, leaving the input untouched.
+func sortedCopy[T number](values []T) []T {
+	sorted := append([]T(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// median returns the median of the values, the mean of the two middle values for an even count.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := sortedCopy(values)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// medianAbsoluteDeviation returns the median and the median absolute deviation of the values.
+func medianAbsoluteDeviation(values []float64) (center, mad float64) {
+	center = median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - center)
+	}
+	return center, median(deviations)
+}
+
+// mannWhitneyU tests whether the values of a and b come from the same distribution, returning the U
+// statistic of a and the two-sided p-value of the normal approximation, corrected for ties and continuity.
+func mannWhitneyU(a, b []float64) (u, p float64) {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type sample struct {
+		value float64
+		fromA bool
+	}
+	samples := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		samples = append(samples, sample{value: v, fromA: true})
+	}
+	for _, v := range b {
+		samples = append(samples, sample{value: v})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].value < samples[j].value })
+
+	// tied values share the average of their ranks
+	var rankSumA, ties float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].value == samples[i].value {
+			j++
+		}
+		rank, count := float64(i+j+1)/2, float64(j-i)
+		for k := i; k < j; k++ {
+			if samples[k].fromA {
+				rankSumA += rank
+			}
+		}
+		ties += count*count*count - count
+		i = j
+	}
+
+	n := n1 + n2
+	u = rankSumA - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return u, 1
+	}
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / sigma
+	return u, math.Erfc(z / math.Sqrt2)
+}
+
+// pearson returns the Pearson correlation of the paired values, false when either side is constant.
+func pearson(x, y []float64) (float64, bool) {
+	n := float64(len(x))
+	if len(x) != len(y) || n < 2 {
+		return 0, false
+	}
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX, meanY = meanX/n, meanY/n
+
+	var covariance, varianceX, varianceY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0, false
+	}
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
+
+// spearman returns the Spearman rank correlation of the paired values, robust to outliers and monotonic but non-linear relations.
+func spearman(x, y []float64) (float64, bool) {
+	return pearson(ranks(x), ranks(y))
+}
+
+// ranks returns the rank of each value from 1, tied values sharing the average of their ranks.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	result := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && values[order[j]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			result[order[k]] = rank
+		}
+		i = j
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	low, high := make([]float64, 10), make([]float64, 10)
+	for i := range low {
+		low[i], high[i] = float64(i+1), float64(i+11)
+	}
+	tests := []struct {
+		name  string
+		a, b  []float64
+		wantU float64
+		wantP float64
+	}{
+		{name: "a below b", a: low, b: high, wantU: 0, wantP: 0.000182672},
+		{name: "a above b", a: high, b: low, wantU: 100, wantP: 0.000182672},
+		{name: "interleaved", a: []float64{1, 3, 5, 7}, b: []float64{2, 4, 6, 8}, wantU: 6, wantP: 0.665006},
+		{name: "ties", a: []float64{1, 2, 2, 3}, b: []float64{2, 3, 4, 5}, wantU: 2.5, wantP: 0.136658},
+		{name: "all tied", a: []float64{5, 5}, b: []float64{5, 5}, wantU: 2, wantP: 1},
+		{name: "empty", a: nil, b: high, wantU: 0, wantP: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, p := mannWhitneyU(tt.a, tt.b)
+			if u != tt.wantU || math.Abs(p-tt.wantP) > 1e-6 {
+				t.Errorf("mannWhitneyU = %v, %.9f, want %v, %.9f", u, p, tt.wantU, tt.wantP)
+			}
+		})
+	}
+}
+
+func TestPearsonAndSpearman(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         []float64
+		wantPearson  float64
+		wantSpearman float64
+		wantOK       bool
+	}{
+		{name: "linear", x: []float64{1, 2, 3, 4}, y: []float64{2, 4, 6, 8}, wantPearson: 1, wantSpearman: 1, wantOK: true},
+		{name: "inverse", x: []float64{1, 2, 3, 4}, y: []float64{8, 6, 4, 2}, wantPearson: -1, wantSpearman: -1, wantOK: true},
+		{name: "monotonic", x: []float64{1, 2, 3, 4}, y: []float64{1, 10, 100, 1000}, wantPearson: 0.8241, wantSpearman: 1, wantOK: true},
+		{name: "outlier", x: []float64{1, 2, 3, 4, 5}, y: []float64{1, 2, 3, 4, -100}, wantPearson: -0.6897, wantSpearman: 0, wantOK: true},
+		{name: "ties", x: []float64{1, 2, 2, 3}, y: []float64{1, 2, 3, 4}, wantPearson: 0.9487, wantSpearman: 0.9487, wantOK: true},
+		{name: "constant", x: []float64{1, 2, 3}, y: []float64{5, 5, 5}},
+		{name: "single point", x: []float64{1}, y: []float64{2}},
+		{name: "unpaired", x: []float64{1, 2, 3}, y: []float64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, pOK := pearson(tt.x, tt.y)
+			s, sOK := spearman(tt.x, tt.y)
+			if pOK != tt.wantOK || sOK != tt.wantOK {
+				t.Fatalf("ok = %v, %v, want %v", pOK, sOK, tt.wantOK)
+			}
+			if math.Abs(p-tt.wantPearson) > 1e-4 || math.Abs(s-tt.wantSpearman) > 1e-4 {
+				t.Errorf("pearson = %.4f, spearman = %.4f, want %.4f, %.4f", p, s, tt.wantPearson, tt.wantSpearman)
+			}
+		})
+	}
+}
@@ -26,6 +26,54 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// Output formats of tool results.
+const (
+	FormatJSON = "json"
+	FormatTree = "tree"
+)
+
+// Renderer is implemented by tool results that support output formats other than JSON.
+type Renderer interface {
+	// Render renders the result in the given format, returning false if the format is not supported
+	// and an error if the result cannot be rendered in a supported format.
+	Render(format string) (string, bool, error)
+}
+
+// SeriesResult is implemented by tool results carrying time series, which can then be rendered
+// in FormatSparkline and FormatChart.
+type SeriesResult interface {
+	TimeSeries() []NamedSeries
+}
+
+// OutputFormat is embedded in the requests of tools that let the caller choose the output format.
+type OutputFormat struct {
+	Format string `json:"format,omitempty"`
+}
+
+func (f OutputFormat) outputFormat() string {
+	return f.Format
+}
+
+type formatSelector interface {
+	outputFormat() string
+}
+
+// WithOutputFormat adds the format argument bound to OutputFormat to a tool,
+// with JSON as the default format.
+func WithOutputFormat(formats ...string) mcp.ToolOption {
+	return mcp.WithString("format", mcp.Enum(append([]string{FormatJSON}, formats...)...),
+		mcp.Description("The output format of the result, defaults to json"))
+}
+
+// combineOptions groups several tool options into one, so that arguments shared by tools are declared once.
+func combineOptions(options ...mcp.ToolOption) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, option := range options {
+			option(tool)
+		}
+	}
+}
+
 type Tool[T any, R any] struct {
 	Name        string
 	Description string
@@ -76,6 +124,11 @@ func ConvertTool[T any, R any](
 		if err := request.BindArguments(&args); err != nil {
 			return nil, fmt.Errorf("failed to bind arguments: %w", err)
 		}
+		if resolver, ok := any(&args).(argumentResolver); ok {
+			if err := resolver.resolveArguments(ctx); err != nil {
+				return nil, fmt.Errorf("failed to resolve arguments: %w", err)
+			}
+		}
 
 		result, err := handlerFunc(ctx, args)
 		if err != nil {
@@ -90,13 +143,40 @@ func ConvertTool[T any, R any](
 		case nil:
 			return nil, nil
 		default:
-			jsonBytes, err := json.Marshal(v)
+			format := FormatJSON
+			if selector, ok := any(args).(formatSelector); ok && selector.outputFormat() != "" {
+				format = selector.outputFormat()
+			}
+			text, err := marshalResult(v, format)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal return value: %s", err)
+				return nil, err
 			}
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return mcp.NewToolResultText(text), nil
 		}
 	}
 
 	return tool, handler, nil
 }
+
+// marshalResult renders the result in the given format, falling back to JSON.
+func marshalResult(v any, format string) (string, error) {
+	if format != FormatJSON {
+		if renderer, ok := v.(Renderer); ok {
+			if text, ok, err := renderer.Render(format); ok || err != nil {
+				return text, err
+			}
+		}
+		if result, ok := v.(SeriesResult); ok {
+			if text, ok := renderCharts(result.TimeSeries(), format); ok {
+				return text, nil
+			}
+		}
+		return "", fmt.Errorf("format %s is not supported by the result", format)
+	}
+
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal return value: %s", err)
+	}
+	return string(jsonBytes), nil
+}
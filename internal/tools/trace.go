@@ -20,6 +20,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/apache/skywalking-cli/pkg/graphql/trace"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -31,23 +34,205 @@ type TraceRequest struct {
 	TraceID string `json:"trace_id"`
 }
 
-func searchTrace(ctx context.Context, req TraceRequest) (*api.Trace, error) {
+// SearchTraceRequest is a trace request whose result can be rendered in the trace formats.
+type SearchTraceRequest struct {
+	TraceRequest
+	OutputFormat
+}
+
+// TraceCondition holds the filters of the trace list query.
+type TraceCondition struct {
+	TimeRange
+	EntitySelector
+	MinDuration int      `json:"min_duration"`
+	MaxDuration int      `json:"max_duration"`
+	State       string   `json:"state"`
+	Order       string   `json:"order"`
+	Tags        []string `json:"tags"`
+}
+
+type QueryTracesRequest struct {
+	TraceCondition
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+// TraceSummary is the compact form of a trace as shown in the trace list of the UI.
+type TraceSummary struct {
+	TraceIDs      []string `json:"trace_ids"`
+	SegmentID     string   `json:"segment_id"`
+	EndpointNames []string `json:"endpoint_names"`
+	Duration      int      `json:"duration_ms"`
+	Start         string   `json:"start"`
+	IsError       bool     `json:"is_error"`
+}
+
+func searchTrace(ctx context.Context, req SearchTraceRequest) (*TraceResult, error) {
 	traces, err := trace.Trace(ctx, req.TraceID)
 	if err != nil {
 		return nil, fmt.Errorf("search trace %v failed: %w", req.TraceID, err)
 	}
+	return &TraceResult{Trace: &traces}, nil
+}
+
+// RenderTrace queries a trace by its ID and renders it in the given format.
+func RenderTrace(ctx context.Context, traceID, format string) (string, error) {
+	traces, err := fetchTrace(ctx, traceID)
+	if err != nil {
+		return "", err
+	}
+	return marshalResult(&TraceResult{Trace: traces}, format)
+}
+
+// fetchTrace queries a trace by its ID, failing if the trace has no spans.
+func fetchTrace(ctx context.Context, traceID string) (*api.Trace, error) {
+	traces, err := trace.Trace(ctx, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("search trace %v failed: %w", traceID, err)
+	}
+	if len(traces.Spans) == 0 {
+		return nil, fmt.Errorf("trace %v not found", traceID)
+	}
 	return &traces, nil
 }
 
+func queryTraces(ctx context.Context, req QueryTracesRequest) ([]TraceSummary, error) {
+	condition, err := req.toQueryCondition(ctx, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	brief, err := trace.Traces(ctx, condition)
+	if err != nil {
+		return nil, fmt.Errorf("query traces failed: %w", err)
+	}
+
+	location := clockOf(ctx).location
+	summaries := make([]TraceSummary, 0, len(brief.Traces))
+	for _, t := range brief.Traces {
+		summaries = append(summaries, TraceSummary{
+			TraceIDs:      t.TraceIds,
+			SegmentID:     t.SegmentID,
+			EndpointNames: t.EndpointNames,
+			Duration:      t.Duration,
+			Start:         formatMillis(t.Start, location),
+			IsError:       t.IsError != nil && *t.IsError,
+		})
+	}
+	return summaries, nil
+}
+
+func (c *TraceCondition) toQueryCondition(ctx context.Context, pageNum, pageSize int) (*api.TraceQueryCondition, error) {
+	duration, err := c.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := api.TraceStateAll
+	if c.State != "" {
+		state = api.TraceState(strings.ToUpper(c.State))
+		if !state.IsValid() {
+			return nil, fmt.Errorf("invalid trace state %q", c.State)
+		}
+	}
+	order := api.QueryOrderByStartTime
+	if strings.EqualFold(c.Order, "duration") {
+		order = api.QueryOrderByDuration
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 15
+	}
+
+	condition := &api.TraceQueryCondition{
+		QueryDuration: &duration,
+		TraceState:    state,
+		QueryOrder:    order,
+		Paging:        &api.Pagination{PageNum: &pageNum, PageSize: pageSize},
+	}
+	if c.MinDuration > 0 {
+		condition.MinTraceDuration = &c.MinDuration
+	}
+	if c.MaxDuration > 0 {
+		condition.MaxTraceDuration = &c.MaxDuration
+	}
+	condition.Tags, err = parseTags(c.Tags, func(key string, value *string) *api.SpanTag {
+		return &api.SpanTag{Key: key, Value: value}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if condition.ServiceID, condition.ServiceInstanceID, condition.EndpointID, err = c.entityIDs(ctx); err != nil {
+		return nil, err
+	}
+
+	return condition, nil
+}
+
+// timestampLayout is the layout of the times of traces, spans and logs in the results.
+const timestampLayout = "2006-01-02 15:04:05.000"
+
+// formatMillis renders a millisecond timestamp string in a human-readable form, in the timezone of OAP.
+func formatMillis(millis string, location *time.Location) string {
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return millis
+	}
+	return time.UnixMilli(ms).In(location).Format(timestampLayout)
+}
+
 func AddTraceTools(mcp *server.MCPServer) {
 	SearchTraceTool.Register(mcp)
+	QueryTracesTool.Register(mcp)
+	AnalyzeTraceTool.Register(mcp)
+	ValidateTraceTool.Register(mcp)
+	CompareTracesTool.Register(mcp)
+	ExtractTraceErrorsTool.Register(mcp)
+	LatencyBreakdownTool.Register(mcp)
+	ListTraceTagsTool.Register(mcp)
 }
 
-var SearchTraceTool = NewTool[TraceRequest, *api.Trace](
+var SearchTraceTool = NewTool[SearchTraceRequest, *TraceResult](
 	"search_trace_by_trace_id",
-	"Search for traces by a single TraceId",
+	"Search for traces by a single TraceId. This is the trace tool exporting the trace as OTLP or Jaeger JSON, "+
+		"keeping the SkyWalking segment and span IDs and refs as attributes, the other trace tools returning JSON only",
 	searchTrace,
 	mcp.WithTitleAnnotation("Search a trace by TraceId"),
 	mcp.WithString("trace_id", mcp.Required(),
 		mcp.Description("The TraceId to search for")),
+	WithOutputFormat(FormatTree, FormatOTLP, FormatJaeger),
+)
+
+var QueryTracesTool = NewTool[QueryTracesRequest, []TraceSummary](
+	"query_traces",
+	"Query the trace list, filtered by service, instance, endpoint, duration, state, tags and time window",
+	queryTraces,
+	mcp.WithTitleAnnotation("Query traces"),
+	WithTraceCondition(),
+	mcp.WithNumber("page_num", mcp.Description("The page number, starting from 1")),
+	mcp.WithNumber("page_size", mcp.Description("The page size, defaults to 15")),
 )
+
+// WithTraceCondition adds the arguments bound to TraceCondition to a tool.
+func WithTraceCondition() mcp.ToolOption {
+	return combineOptions(
+		mcp.WithString("service_id", mcp.Description("The ID of the service the traces pass through")),
+		mcp.WithString("service_name", mcp.Description("The name of the service, partial names are resolved, used when service_id is not given")),
+		mcp.WithString("service_instance_id", mcp.Description("The ID of the service instance")),
+		mcp.WithString("service_instance_name", mcp.Description("The name of the service instance, partial names are resolved, requires the service")),
+		mcp.WithString("endpoint_id", mcp.Description("The ID of the endpoint")),
+		mcp.WithString("endpoint_name", mcp.Description("The name of the endpoint, partial names are resolved, requires the service")),
+		mcp.WithNumber("min_duration", mcp.Description("Minimum trace duration in milliseconds")),
+		mcp.WithNumber("max_duration", mcp.Description("Maximum trace duration in milliseconds")),
+		mcp.WithString("state", mcp.Enum(string(api.TraceStateAll), string(api.TraceStateSuccess), string(api.TraceStateError)),
+			mcp.Description("Filter traces by state, defaults to ALL")),
+		mcp.WithString("order", mcp.Enum("start_time", "duration"),
+			mcp.Description("Order the traces by start time or duration, defaults to start_time")),
+		mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Span tags the traces must carry, each as key=value, e.g. http.method=GET. "+
+				"The available tags are listed by list_trace_tags")),
+		WithTimeRange(),
+	)
+}
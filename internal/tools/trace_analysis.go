@@ -0,0 +1,131 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// topContributors is the number of spans listed by self time in the analysis.
+const topContributors = 10
+
+// TraceAnalysis is the latency breakdown of a single trace.
+type TraceAnalysis struct {
+	TraceID      string         `json:"trace_id"`
+	Duration     int64          `json:"duration_ms"`
+	SpanCount    int            `json:"span_count"`
+	SegmentCount int            `json:"segment_count"`
+	Services     []string       `json:"services"`
+	CriticalPath []AnalyzedSpan `json:"critical_path"`
+	TopSelfTime  []AnalyzedSpan `json:"top_self_time"`
+	ErrorSpans   []AnalyzedSpan `json:"error_spans,omitempty"`
+}
+
+// AnalyzedSpan is a span with the timings computed from the span tree.
+type AnalyzedSpan struct {
+	SegmentID    string `json:"segment_id"`
+	SpanID       int    `json:"span_id"`
+	Service      string `json:"service"`
+	Endpoint     string `json:"endpoint,omitempty"`
+	Type         string `json:"type"`
+	Component    string `json:"component,omitempty"`
+	Peer         string `json:"peer,omitempty"`
+	Duration     int64  `json:"duration_ms"`
+	SelfTime     int64  `json:"self_time_ms"`
+	CriticalTime int64  `json:"critical_time_ms,omitempty"`
+	IsError      bool   `json:"is_error,omitempty"`
+}
+
+func analyzeTrace(ctx context.Context, req TraceRequest) (*TraceAnalysis, error) {
+	traces, err := fetchTrace(ctx, req.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := buildSpanTree(traces)
+	analysis := &TraceAnalysis{
+		TraceID:   req.TraceID,
+		Duration:  tree.duration(),
+		SpanCount: len(tree.ordered),
+	}
+
+	segments, services := map[string]bool{}, map[string]bool{}
+	for _, node := range tree.ordered {
+		segments[node.span.SegmentID] = true
+		if !services[node.span.ServiceCode] {
+			services[node.span.ServiceCode] = true
+			analysis.Services = append(analysis.Services, node.span.ServiceCode)
+		}
+		if node.isError() {
+			analysis.ErrorSpans = append(analysis.ErrorSpans, newAnalyzedSpan(node, 0))
+		}
+	}
+	analysis.SegmentCount = len(segments)
+
+	path, contribution := tree.criticalPath()
+	for _, node := range path {
+		analysis.CriticalPath = append(analysis.CriticalPath, newAnalyzedSpan(node, contribution[node]))
+	}
+
+	bySelfTime := append([]*spanNode(nil), tree.ordered...)
+	sort.SliceStable(bySelfTime, func(i, j int) bool {
+		return bySelfTime[i].selfTime > bySelfTime[j].selfTime
+	})
+	for _, node := range bySelfTime[:min(topContributors, len(bySelfTime))] {
+		analysis.TopSelfTime = append(analysis.TopSelfTime, newAnalyzedSpan(node, contribution[node]))
+	}
+
+	return analysis, nil
+}
+
+func newAnalyzedSpan(node *spanNode, criticalTime int64) AnalyzedSpan {
+	span := node.span
+	return AnalyzedSpan{
+		SegmentID:    span.SegmentID,
+		SpanID:       span.SpanID,
+		Service:      span.ServiceCode,
+		Endpoint:     stringValue(span.EndpointName),
+		Type:         span.Type,
+		Component:    stringValue(span.Component),
+		Peer:         stringValue(span.Peer),
+		Duration:     node.duration(),
+		SelfTime:     node.selfTime,
+		CriticalTime: criticalTime,
+		IsError:      node.isError(),
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+var AnalyzeTraceTool = NewTool[TraceRequest, *TraceAnalysis](
+	"analyze_trace",
+	"Analyze a trace by its TraceId: rebuild the span tree across segments and report the critical path, "+
+		"the spans with the highest self time and the error spans",
+	analyzeTrace,
+	mcp.WithTitleAnnotation("Analyze the latency of a trace"),
+	mcp.WithString("trace_id", mcp.Required(),
+		mcp.Description("The TraceId to analyze")),
+)
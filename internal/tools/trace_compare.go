@@ -0,0 +1,222 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultMinDelta  = 10
+	defaultDiffLimit = 20
+	pathSeparator    = " → "
+	occurrenceMarker = "#"
+)
+
+type CompareTracesRequest struct {
+	BaselineTraceID string `json:"baseline_trace_id"`
+	TargetTraceID   string `json:"target_trace_id"`
+	MinDelta        int64  `json:"min_delta"`
+	Limit           int    `json:"limit"`
+}
+
+// TraceComparison is the diff of two span trees aligned by service, endpoint and operation name.
+type TraceComparison struct {
+	BaselineTraceID  string     `json:"baseline_trace_id"`
+	TargetTraceID    string     `json:"target_trace_id"`
+	BaselineDuration int64      `json:"baseline_duration_ms"`
+	TargetDuration   int64      `json:"target_duration_ms"`
+	DurationDelta    int64      `json:"duration_delta_ms"`
+	MatchedSpans     int        `json:"matched_spans"`
+	Added            []SpanDiff `json:"added,omitempty"`
+	Removed          []SpanDiff `json:"removed,omitempty"`
+	Slower           []SpanDiff `json:"slower,omitempty"`
+	Faster           []SpanDiff `json:"faster,omitempty"`
+}
+
+// SpanDiff is a span present in either or both traces, identified by its path from the root.
+type SpanDiff struct {
+	Path             string `json:"path"`
+	Service          string `json:"service"`
+	Endpoint         string `json:"endpoint,omitempty"`
+	Type             string `json:"type"`
+	BaselineDuration int64  `json:"baseline_duration_ms,omitempty"`
+	TargetDuration   int64  `json:"target_duration_ms,omitempty"`
+	DurationDelta    int64  `json:"duration_delta_ms"`
+	SelfTimeDelta    int64  `json:"self_time_delta_ms"`
+}
+
+func compareTraces(ctx context.Context, req CompareTracesRequest) (*TraceComparison, error) {
+	if req.BaselineTraceID == "" || req.TargetTraceID == "" {
+		return nil, fmt.Errorf("both baseline_trace_id and target_trace_id are required")
+	}
+	baseline, err := fetchTrace(ctx, req.BaselineTraceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fetchTrace(ctx, req.TargetTraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	minDelta, limit := req.MinDelta, req.Limit
+	if minDelta <= 0 {
+		minDelta = defaultMinDelta
+	}
+	if limit <= 0 {
+		limit = defaultDiffLimit
+	}
+
+	comparison := diffSpanTrees(buildSpanTree(baseline), buildSpanTree(target), minDelta)
+	comparison.BaselineTraceID, comparison.TargetTraceID = req.BaselineTraceID, req.TargetTraceID
+	comparison.Added = truncateDiffs(comparison.Added, limit)
+	comparison.Removed = truncateDiffs(comparison.Removed, limit)
+	comparison.Slower = truncateDiffs(comparison.Slower, limit)
+	comparison.Faster = truncateDiffs(comparison.Faster, limit)
+	return comparison, nil
+}
+
+// diffSpanTrees aligns the spans of both trees by their paths and reports the
+// spans only in one of them and the spans whose duration changed by at least minDelta.
+func diffSpanTrees(baseline, target *spanTree, minDelta int64) *TraceComparison {
+	comparison := &TraceComparison{
+		BaselineDuration: baseline.duration(),
+		TargetDuration:   target.duration(),
+	}
+	comparison.DurationDelta = comparison.TargetDuration - comparison.BaselineDuration
+
+	baselinePaths, targetPaths := alignSpanPaths(baseline), alignSpanPaths(target)
+	for _, path := range sortedPaths(baselinePaths) {
+		before := baselinePaths[path]
+		after, ok := targetPaths[path]
+		if !ok {
+			diff := newSpanDiff(path, before)
+			diff.BaselineDuration = before.duration()
+			diff.DurationDelta, diff.SelfTimeDelta = -before.duration(), -before.selfTime
+			comparison.Removed = append(comparison.Removed, diff)
+			continue
+		}
+
+		comparison.MatchedSpans++
+		diff := newSpanDiff(path, after)
+		diff.BaselineDuration, diff.TargetDuration = before.duration(), after.duration()
+		diff.DurationDelta = after.duration() - before.duration()
+		diff.SelfTimeDelta = after.selfTime - before.selfTime
+		switch {
+		case diff.DurationDelta >= minDelta:
+			comparison.Slower = append(comparison.Slower, diff)
+		case -diff.DurationDelta >= minDelta:
+			comparison.Faster = append(comparison.Faster, diff)
+		}
+	}
+	for _, path := range sortedPaths(targetPaths) {
+		if _, ok := baselinePaths[path]; ok {
+			continue
+		}
+		after := targetPaths[path]
+		diff := newSpanDiff(path, after)
+		diff.TargetDuration = after.duration()
+		diff.DurationDelta, diff.SelfTimeDelta = after.duration(), after.selfTime
+		comparison.Added = append(comparison.Added, diff)
+	}
+
+	for _, diffs := range [][]SpanDiff{comparison.Added, comparison.Removed, comparison.Slower, comparison.Faster} {
+		sort.SliceStable(diffs, func(i, j int) bool {
+			return abs(diffs[i].DurationDelta) > abs(diffs[j].DurationDelta)
+		})
+	}
+	return comparison
+}
+
+// alignSpanPaths keys every span by the service and endpoint of its ancestors and
+// itself. Siblings sharing the same service and endpoint are told apart by their
+// occurrence in start time order.
+func alignSpanPaths(tree *spanTree) map[string]*spanNode {
+	paths := make(map[string]*spanNode, len(tree.ordered))
+	var walk func(nodes []*spanNode, prefix string)
+	walk = func(nodes []*spanNode, prefix string) {
+		occurrences := make(map[string]int)
+		for _, node := range nodes {
+			key := node.span.ServiceCode + ":" + stringValue(node.span.EndpointName)
+			name := key
+			if n := occurrences[key]; n > 0 {
+				name += fmt.Sprintf("%s%d", occurrenceMarker, n+1)
+			}
+			occurrences[key]++
+
+			path := name
+			if prefix != "" {
+				path = prefix + pathSeparator + name
+			}
+			paths[path] = node
+			walk(node.children, path)
+		}
+	}
+	walk(tree.roots, "")
+	return paths
+}
+
+func sortedPaths(paths map[string]*spanNode) []string {
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newSpanDiff(path string, node *spanNode) SpanDiff {
+	return SpanDiff{
+		Path:     path,
+		Service:  node.span.ServiceCode,
+		Endpoint: stringValue(node.span.EndpointName),
+		Type:     node.span.Type,
+	}
+}
+
+func truncateDiffs(diffs []SpanDiff, limit int) []SpanDiff {
+	if len(diffs) > limit {
+		return diffs[:limit]
+	}
+	return diffs
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+var CompareTracesTool = NewTool[CompareTracesRequest, *TraceComparison](
+	"compare_traces",
+	"Compare two traces, e.g. a fast and a slow trace of the same endpoint. The span trees are aligned by service, "+
+		"endpoint and operation name, reporting the spans that were added, removed, or became slower or faster",
+	compareTraces,
+	mcp.WithTitleAnnotation("Compare two traces"),
+	mcp.WithString("baseline_trace_id", mcp.Required(),
+		mcp.Description("The TraceId of the baseline trace, e.g. the fast one")),
+	mcp.WithString("target_trace_id", mcp.Required(),
+		mcp.Description("The TraceId of the trace compared with the baseline, e.g. the slow one")),
+	mcp.WithNumber("min_delta", mcp.Description("Minimum duration change in milliseconds to report a span as slower or faster, defaults to 10")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of spans reported in each category, defaults to 20")),
+)
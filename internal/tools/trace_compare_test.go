@@ -0,0 +1,141 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// namedSpan renames the endpoint of the span, aligning spans of different IDs.
+func namedSpan(span *api.Span, endpoint string) *api.Span {
+	span.EndpointName = &endpoint
+	return span
+}
+
+// diffSummary lists the path and duration delta of the diffs in their order.
+func diffSummary(diffs []SpanDiff) []string {
+	var summary []string
+	for _, diff := range diffs {
+		summary = append(summary, fmt.Sprintf("%s %+d", diff.Path, diff.DurationDelta))
+	}
+	return summary
+}
+
+func TestDiffSpanTrees(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseline    []*api.Span
+		target      []*api.Span
+		wantDelta   int64
+		wantMatched int
+		wantAdded   []string
+		wantRemoved []string
+		wantSlower  []string
+		wantFaster  []string
+	}{
+		{
+			name:        "slower",
+			baseline:    []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 50)},
+			target:      []*api.Span{testSpan("s1", 0, -1, 0, 150), testSpan("s1", 1, 0, 10, 60)},
+			wantDelta:   50,
+			wantMatched: 2,
+			wantSlower:  []string{"svc:s1/0 +50", "svc:s1/0 → svc:s1/1 +10"},
+		},
+		{
+			name:        "faster",
+			baseline:    []*api.Span{testSpan("s1", 0, -1, 0, 150), testSpan("s1", 1, 0, 10, 60)},
+			target:      []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 50)},
+			wantDelta:   -50,
+			wantMatched: 2,
+			wantFaster:  []string{"svc:s1/0 -50", "svc:s1/0 → svc:s1/1 -10"},
+		},
+		{
+			name:        "below the minimum delta",
+			baseline:    []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 50)},
+			target:      []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 55)},
+			wantMatched: 2,
+		},
+		{
+			name:        "added and removed",
+			baseline:    []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 50)},
+			target:      []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 2, 0, 20, 40)},
+			wantMatched: 1,
+			wantAdded:   []string{"svc:s1/0 → svc:s1/2 +20"},
+			wantRemoved: []string{"svc:s1/0 → svc:s1/1 -40"},
+		},
+		{
+			name: "repeated siblings",
+			baseline: []*api.Span{
+				namedSpan(testSpan("s1", 0, -1, 0, 100), "root"),
+				namedSpan(testSpan("s1", 1, 0, 10, 20), "query"),
+				namedSpan(testSpan("s1", 2, 0, 30, 40), "query"),
+			},
+			target: []*api.Span{
+				namedSpan(testSpan("s1", 0, -1, 0, 100), "root"),
+				namedSpan(testSpan("s1", 1, 0, 10, 20), "query"),
+				namedSpan(testSpan("s1", 2, 0, 30, 70), "query"),
+				namedSpan(testSpan("s1", 3, 0, 80, 90), "query"),
+			},
+			wantMatched: 3,
+			wantAdded:   []string{"svc:root → svc:query#3 +10"},
+			wantSlower:  []string{"svc:root → svc:query#2 +30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := diffSpanTrees(
+				buildSpanTree(&api.Trace{Spans: tt.baseline}), buildSpanTree(&api.Trace{Spans: tt.target}), defaultMinDelta)
+			if comparison.DurationDelta != tt.wantDelta {
+				t.Errorf("duration delta = %d, want %d", comparison.DurationDelta, tt.wantDelta)
+			}
+			if comparison.MatchedSpans != tt.wantMatched {
+				t.Errorf("matched spans = %d, want %d", comparison.MatchedSpans, tt.wantMatched)
+			}
+			for _, c := range []struct {
+				name      string
+				got, want []string
+			}{
+				{"added", diffSummary(comparison.Added), tt.wantAdded},
+				{"removed", diffSummary(comparison.Removed), tt.wantRemoved},
+				{"slower", diffSummary(comparison.Slower), tt.wantSlower},
+				{"faster", diffSummary(comparison.Faster), tt.wantFaster},
+			} {
+				if !reflect.DeepEqual(c.got, c.want) {
+					t.Errorf("%s = %q, want %q", c.name, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffSpanTreesSelfTime(t *testing.T) {
+	baseline := buildSpanTree(&api.Trace{Spans: []*api.Span{testSpan("s1", 0, -1, 0, 100), testSpan("s1", 1, 0, 10, 50)}})
+	target := buildSpanTree(&api.Trace{Spans: []*api.Span{testSpan("s1", 0, -1, 0, 150), testSpan("s1", 1, 0, 10, 50)}})
+
+	comparison := diffSpanTrees(baseline, target, defaultMinDelta)
+	if len(comparison.Slower) != 1 {
+		t.Fatalf("slower = %q, want the root only", diffSummary(comparison.Slower))
+	}
+	if got := comparison.Slower[0].SelfTimeDelta; got != 50 {
+		t.Errorf("self time delta = %d, want 50", got)
+	}
+}
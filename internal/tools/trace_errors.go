@@ -0,0 +1,204 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const defaultMaxStackFrames = 10
+
+type TraceErrorsRequest struct {
+	TraceID        string `json:"trace_id"`
+	MaxStackFrames int    `json:"max_stack_frames"`
+}
+
+// TraceErrors holds the error spans of a trace together with their ancestors.
+type TraceErrors struct {
+	TraceID    string      `json:"trace_id"`
+	ErrorCount int         `json:"error_count"`
+	Spans      []ErrorSpan `json:"spans"`
+}
+
+// ErrorSpan is an error span or an ancestor of one, the depth is relative to the root of the trace.
+type ErrorSpan struct {
+	SegmentID string            `json:"segment_id"`
+	SpanID    int               `json:"span_id"`
+	Depth     int               `json:"depth"`
+	Service   string            `json:"service"`
+	Instance  string            `json:"instance"`
+	Endpoint  string            `json:"endpoint,omitempty"`
+	Type      string            `json:"type"`
+	Component string            `json:"component,omitempty"`
+	Peer      string            `json:"peer,omitempty"`
+	Duration  int64             `json:"duration_ms"`
+	IsError   bool              `json:"is_error"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Logs      []SpanLog         `json:"logs,omitempty"`
+}
+
+// SpanLog is a log of a span, such as a recorded exception.
+type SpanLog struct {
+	Time   string            `json:"time"`
+	Fields map[string]string `json:"fields"`
+}
+
+func extractTraceErrors(ctx context.Context, req TraceErrorsRequest) (*TraceErrors, error) {
+	traces, err := fetchTrace(ctx, req.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	maxFrames := req.MaxStackFrames
+	if maxFrames <= 0 {
+		maxFrames = defaultMaxStackFrames
+	}
+
+	location := clockOf(ctx).location
+	tree := buildSpanTree(traces)
+	result := &TraceErrors{TraceID: req.TraceID, Spans: []ErrorSpan{}}
+
+	// keep the error spans and all their ancestors, then emit them in tree order
+	kept := make(map[*spanNode]bool)
+	for _, node := range tree.ordered {
+		if !node.isError() {
+			continue
+		}
+		result.ErrorCount++
+		for n := node; n != nil && !kept[n]; n = n.parent {
+			kept[n] = true
+		}
+	}
+
+	var walk func(node *spanNode, depth int)
+	walk = func(node *spanNode, depth int) {
+		if !kept[node] {
+			return
+		}
+		result.Spans = append(result.Spans, newErrorSpan(node, depth, maxFrames, location))
+		for _, child := range node.children {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range tree.roots {
+		walk(root, 0)
+	}
+	return result, nil
+}
+
+// newErrorSpan converts a span, keeping the tags and logs of error spans only.
+func newErrorSpan(node *spanNode, depth, maxFrames int, location *time.Location) ErrorSpan {
+	span := node.span
+	errorSpan := ErrorSpan{
+		SegmentID: span.SegmentID,
+		SpanID:    span.SpanID,
+		Depth:     depth,
+		Service:   span.ServiceCode,
+		Instance:  span.ServiceInstanceName,
+		Endpoint:  stringValue(span.EndpointName),
+		Type:      span.Type,
+		Component: stringValue(span.Component),
+		Peer:      stringValue(span.Peer),
+		Duration:  node.duration(),
+		IsError:   node.isError(),
+	}
+	if !errorSpan.IsError {
+		return errorSpan
+	}
+
+	if len(span.Tags) > 0 {
+		errorSpan.Tags = make(map[string]string, len(span.Tags))
+		for _, tag := range span.Tags {
+			if tag != nil {
+				errorSpan.Tags[tag.Key] = stringValue(tag.Value)
+			}
+		}
+	}
+	for _, log := range span.Logs {
+		if log == nil {
+			continue
+		}
+		fields := make(map[string]string, len(log.Data))
+		for _, kv := range log.Data {
+			if kv == nil {
+				continue
+			}
+			value := stringValue(kv.Value)
+			if kv.Key == "stack" {
+				value = shortenStackTrace(value, maxFrames)
+			}
+			fields[kv.Key] = value
+		}
+		errorSpan.Logs = append(errorSpan.Logs, SpanLog{
+			Time:   time.UnixMilli(log.Time).In(location).Format(timestampLayout),
+			Fields: fields,
+		})
+	}
+	return errorSpan
+}
+
+// shortenStackTrace keeps the exception lines, including the "Caused by" ones,
+// and at most maxFrames stack frames below each of them.
+func shortenStackTrace(stack string, maxFrames int) string {
+	lines := strings.Split(strings.TrimRight(stack, "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	frames, omitted := 0, 0
+	flush := func() {
+		if omitted > 0 {
+			kept = append(kept, fmt.Sprintf("\t... %d more frames omitted", omitted))
+		}
+		frames, omitted = 0, 0
+	}
+	for _, line := range lines {
+		if !isStackFrame(line) {
+			flush()
+			kept = append(kept, line)
+			continue
+		}
+		if frames < maxFrames {
+			kept = append(kept, line)
+			frames++
+		} else {
+			omitted++
+		}
+	}
+	flush()
+	return strings.Join(kept, "\n")
+}
+
+// isStackFrame treats indented lines as frames, which covers the "\tat" lines of Java
+// and the "  File" lines of Python, while exception lines start at the beginning of the line.
+func isStackFrame(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "at ")
+}
+
+var ExtractTraceErrorsTool = NewTool[TraceErrorsRequest, *TraceErrors](
+	"extract_trace_errors",
+	"Extract the error spans of a trace by its TraceId, together with their ancestors up to the entry span "+
+		"and their logs, with exception stack traces shortened",
+	extractTraceErrors,
+	mcp.WithTitleAnnotation("Extract the errors of a trace"),
+	mcp.WithString("trace_id", mcp.Required(),
+		mcp.Description("The TraceId to extract the errors from")),
+	mcp.WithNumber("max_stack_frames",
+		mcp.Description("Maximum number of stack frames kept below each exception line, defaults to 10")),
+)
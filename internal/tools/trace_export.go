@@ -0,0 +1,518 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// Export formats of traces, in addition to FormatJSON and FormatTree.
+const (
+	FormatOTLP   = "otlp"
+	FormatJaeger = "jaeger"
+)
+
+// Attributes carrying the SkyWalking fields that have no equivalent in OTLP and Jaeger,
+// so that the original segment model can be restored from the export.
+const (
+	attrTraceID    = "sw.trace_id"
+	attrSegmentID  = "sw.segment_id"
+	attrSpanID     = "sw.span_id"
+	attrParentSpan = "sw.parent_span_id"
+	attrSpanType   = "sw.span_type"
+	attrLayer      = "sw.layer"
+	attrComponent  = "sw.component"
+	attrPeer       = "sw.peer"
+	attrRefType    = "sw.ref_type"
+	attrInstance   = "service.instance.id"
+	attrService    = "service.name"
+	// the IDs of the ref to the parent segment, which the exported parent span ID only holds hashed
+	attrRefTraceID   = "sw.ref.trace_id"
+	attrRefSegmentID = "sw.ref.segment_id"
+	attrRefSpanID    = "sw.ref.span_id"
+	// Jaeger references carry no tags, the other refs are kept as sw.link.<n>.<field> span tags instead
+	attrLinkPrefix = "sw.link."
+)
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace.proto.
+const (
+	otlpKindInternal = 1
+	otlpKindServer   = 2
+	otlpKindClient   = 3
+	otlpKindProducer = 4
+	otlpKindConsumer = 5
+	otlpStatusError  = 2
+)
+
+type otlpTrace struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+}
+
+type otlpLink struct {
+	TraceID    string         `json:"traceId"`
+	SpanID     string         `json:"spanId"`
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code,omitempty"`
+}
+
+type jaegerTraces struct {
+	Data []*jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                    `json:"traceID"`
+	Spans     []*jaegerSpan             `json:"spans"`
+	Processes map[string]*jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"`
+	Duration      int64             `json:"duration"`
+	Tags          []jaegerTag       `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerTag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jaegerLog struct {
+	Timestamp int64       `json:"timestamp"`
+	Fields    []jaegerTag `json:"fields"`
+}
+
+type jaegerProcess struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []jaegerTag `json:"tags"`
+}
+
+// exportTrace renders the trace as OTLP JSON or Jaeger JSON.
+func exportTrace(trace *api.Trace, format string) (string, error) {
+	var exported any
+	switch format {
+	case FormatOTLP:
+		exported = toOTLP(trace)
+	case FormatJaeger:
+		exported = toJaeger(trace)
+	default:
+		return "", fmt.Errorf("format %s is not an export format", format)
+	}
+	bytes, err := json.Marshal(exported)
+	if err != nil {
+		return "", fmt.Errorf("export trace as %s failed: %w", format, err)
+	}
+	return string(bytes), nil
+}
+
+// toOTLP groups the spans into resource spans by service and instance.
+func toOTLP(trace *api.Trace) *otlpTrace {
+	result := &otlpTrace{ResourceSpans: []*otlpResourceSpans{}}
+	resources := make(map[[2]string]*otlpScopeSpans)
+	for _, span := range trace.Spans {
+		key := [2]string{span.ServiceCode, span.ServiceInstanceName}
+		scope, ok := resources[key]
+		if !ok {
+			scope = &otlpScopeSpans{Scope: otlpScope{Name: "skywalking"}}
+			resources[key] = scope
+			result.ResourceSpans = append(result.ResourceSpans, &otlpResourceSpans{
+				Resource: otlpResource{Attributes: []otlpKeyValue{
+					otlpString(attrService, span.ServiceCode),
+					otlpString(attrInstance, span.ServiceInstanceName),
+				}},
+				ScopeSpans: []*otlpScopeSpans{scope},
+			})
+		}
+		scope.Spans = append(scope.Spans, toOTLPSpan(span))
+	}
+	return result
+}
+
+func toOTLPSpan(span *api.Span) *otlpSpan {
+	traceID := exportTraceID(span.TraceID)
+	parentSegmentID, parentSpanID, links := exportParent(span)
+	result := &otlpSpan{
+		TraceID:           traceID,
+		SpanID:            exportSpanID(span.SegmentID, span.SpanID),
+		Name:              stringValue(span.EndpointName),
+		Kind:              otlpKind(span),
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime*1e6, 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime*1e6, 10),
+	}
+	if parentSegmentID != "" {
+		result.ParentSpanID = exportSpanID(parentSegmentID, parentSpanID)
+	}
+	for _, ref := range links {
+		result.Links = append(result.Links, otlpLink{
+			TraceID: exportTraceID(ref.TraceID),
+			SpanID:  exportSpanID(ref.ParentSegmentID, ref.ParentSpanID),
+			Attributes: []otlpKeyValue{
+				otlpString(attrTraceID, ref.TraceID),
+				otlpString(attrSegmentID, ref.ParentSegmentID),
+				otlpInt(attrSpanID, int64(ref.ParentSpanID)),
+				otlpString(attrRefType, string(ref.Type)),
+			},
+		})
+	}
+
+	for _, kv := range spanAttributes(span) {
+		result.Attributes = append(result.Attributes, otlpString(kv[0], kv[1]))
+	}
+	result.Attributes = append(result.Attributes, otlpInt(attrSpanID, int64(span.SpanID)), otlpInt(attrParentSpan, int64(span.ParentSpanID)))
+
+	for _, log := range span.Logs {
+		if log == nil {
+			continue
+		}
+		event := otlpEvent{TimeUnixNano: strconv.FormatInt(log.Time*1e6, 10), Name: "log"}
+		for _, kv := range log.Data {
+			if kv == nil {
+				continue
+			}
+			if kv.Key == "event" {
+				event.Name = stringValue(kv.Value)
+			}
+			event.Attributes = append(event.Attributes, otlpString(kv.Key, stringValue(kv.Value)))
+		}
+		result.Events = append(result.Events, event)
+	}
+	for _, attached := range span.AttachedEvents {
+		if attached == nil || attached.StartTime == nil {
+			continue
+		}
+		event := otlpEvent{
+			TimeUnixNano: strconv.FormatInt(attached.StartTime.Seconds*1e9+int64(attached.StartTime.Nanos), 10),
+			Name:         attached.Event,
+		}
+		for _, tag := range attached.Tags {
+			if tag != nil {
+				event.Attributes = append(event.Attributes, otlpString(tag.Key, stringValue(tag.Value)))
+			}
+		}
+		for _, summary := range attached.Summary {
+			if summary != nil {
+				event.Attributes = append(event.Attributes, otlpInt(summary.Key, summary.Value))
+			}
+		}
+		result.Events = append(result.Events, event)
+	}
+	if span.IsError != nil && *span.IsError {
+		result.Status.Code = otlpStatusError
+	}
+	return result
+}
+
+// toJaeger converts the trace with one process per service instance.
+func toJaeger(trace *api.Trace) *jaegerTraces {
+	result := &jaegerTrace{Spans: []*jaegerSpan{}, Processes: make(map[string]*jaegerProcess)}
+	processes := make(map[[2]string]string)
+	for _, span := range trace.Spans {
+		key := [2]string{span.ServiceCode, span.ServiceInstanceName}
+		processID, ok := processes[key]
+		if !ok {
+			processID = "p" + strconv.Itoa(len(processes)+1)
+			processes[key] = processID
+			result.Processes[processID] = &jaegerProcess{
+				ServiceName: span.ServiceCode,
+				Tags:        []jaegerTag{jaegerString(attrInstance, span.ServiceInstanceName)},
+			}
+		}
+		if result.TraceID == "" {
+			result.TraceID = exportTraceID(span.TraceID)
+		}
+		result.Spans = append(result.Spans, toJaegerSpan(span, processID))
+	}
+	return &jaegerTraces{Data: []*jaegerTrace{result}}
+}
+
+func toJaegerSpan(span *api.Span, processID string) *jaegerSpan {
+	traceID := exportTraceID(span.TraceID)
+	result := &jaegerSpan{
+		TraceID:       traceID,
+		SpanID:        exportSpanID(span.SegmentID, span.SpanID),
+		OperationName: stringValue(span.EndpointName),
+		References:    []jaegerReference{},
+		StartTime:     span.StartTime * 1000,
+		Duration:      (span.EndTime - span.StartTime) * 1000,
+		ProcessID:     processID,
+	}
+	parentSegmentID, parentSpanID, links := exportParent(span)
+	if parentSegmentID != "" {
+		result.References = append(result.References, jaegerReference{
+			RefType: "CHILD_OF", TraceID: traceID, SpanID: exportSpanID(parentSegmentID, parentSpanID),
+		})
+	}
+	for i, ref := range links {
+		result.References = append(result.References, jaegerReference{
+			RefType: "FOLLOWS_FROM", TraceID: exportTraceID(ref.TraceID), SpanID: exportSpanID(ref.ParentSegmentID, ref.ParentSpanID),
+		})
+		prefix := attrLinkPrefix + strconv.Itoa(i+1) + "."
+		result.Tags = append(result.Tags,
+			jaegerString(prefix+"trace_id", ref.TraceID),
+			jaegerString(prefix+"segment_id", ref.ParentSegmentID),
+			jaegerTag{Key: prefix + "span_id", Type: "int64", Value: ref.ParentSpanID},
+			jaegerString(prefix+"ref_type", string(ref.Type)),
+		)
+	}
+
+	for _, kv := range spanAttributes(span) {
+		result.Tags = append(result.Tags, jaegerString(kv[0], kv[1]))
+	}
+	result.Tags = append(result.Tags,
+		jaegerTag{Key: attrSpanID, Type: "int64", Value: span.SpanID},
+		jaegerTag{Key: attrParentSpan, Type: "int64", Value: span.ParentSpanID},
+		jaegerString("span.kind", jaegerKind(otlpKind(span))),
+	)
+	if span.IsError != nil && *span.IsError {
+		result.Tags = append(result.Tags, jaegerTag{Key: "error", Type: "bool", Value: true})
+	}
+
+	result.Logs = jaegerLogs(span)
+	return result
+}
+
+// jaegerLogs converts the logs and the attached events of a span, the events being logs
+// with the event name, the tags and the summary as fields.
+func jaegerLogs(span *api.Span) []jaegerLog {
+	logs := []jaegerLog{}
+	for _, log := range span.Logs {
+		if log == nil {
+			continue
+		}
+		entry := jaegerLog{Timestamp: log.Time * 1000, Fields: []jaegerTag{}}
+		for _, kv := range log.Data {
+			if kv != nil {
+				entry.Fields = append(entry.Fields, jaegerString(kv.Key, stringValue(kv.Value)))
+			}
+		}
+		logs = append(logs, entry)
+	}
+	for _, attached := range span.AttachedEvents {
+		if attached == nil || attached.StartTime == nil {
+			continue
+		}
+		entry := jaegerLog{
+			Timestamp: attached.StartTime.Seconds*1e6 + int64(attached.StartTime.Nanos)/1000,
+			Fields:    []jaegerTag{jaegerString("event", attached.Event)},
+		}
+		for _, tag := range attached.Tags {
+			if tag != nil {
+				entry.Fields = append(entry.Fields, jaegerString(tag.Key, stringValue(tag.Value)))
+			}
+		}
+		for _, summary := range attached.Summary {
+			if summary != nil {
+				entry.Fields = append(entry.Fields, jaegerTag{Key: summary.Key, Type: "int64", Value: summary.Value})
+			}
+		}
+		logs = append(logs, entry)
+	}
+	return logs
+}
+
+// spanAttributes returns the SkyWalking fields and the tags of a span as key value pairs.
+func spanAttributes(span *api.Span) [][2]string {
+	attributes := [][2]string{
+		{attrTraceID, span.TraceID},
+		{attrSegmentID, span.SegmentID},
+		{attrSpanType, span.Type},
+	}
+	optional := []struct {
+		key   string
+		value *string
+	}{{attrLayer, span.Layer}, {attrComponent, span.Component}, {attrPeer, span.Peer}}
+	for _, field := range optional {
+		if value := stringValue(field.value); value != "" {
+			attributes = append(attributes, [2]string{field.key, value})
+		}
+	}
+	if ref := parentRef(span); ref != nil {
+		// the ref exported as the parent, the other refs carry theirs in the links
+		attributes = append(attributes,
+			[2]string{attrRefTraceID, ref.TraceID},
+			[2]string{attrRefSegmentID, ref.ParentSegmentID},
+			[2]string{attrRefSpanID, strconv.Itoa(ref.ParentSpanID)},
+			[2]string{attrRefType, string(ref.Type)},
+		)
+	}
+	for _, tag := range span.Tags {
+		if tag != nil {
+			attributes = append(attributes, [2]string{tag.Key, stringValue(tag.Value)})
+		}
+	}
+	return attributes
+}
+
+// parentRef returns the ref exported as the parent of the first span of a segment, nil for other spans.
+func parentRef(span *api.Span) *api.Ref {
+	if span.ParentSpanID >= 0 {
+		return nil
+	}
+	for _, ref := range span.Refs {
+		if ref != nil {
+			return ref
+		}
+	}
+	return nil
+}
+
+// exportParent returns the parent of a span, the first ref of a segment being the
+// parent and the other refs being exported as links.
+func exportParent(span *api.Span) (parentSegmentID string, parentSpanID int, links []*api.Ref) {
+	if span.ParentSpanID >= 0 {
+		return span.SegmentID, span.ParentSpanID, nil
+	}
+	parent := parentRef(span)
+	if parent == nil {
+		return "", 0, nil
+	}
+	for _, ref := range span.Refs {
+		if ref != nil && ref != parent {
+			links = append(links, ref)
+		}
+	}
+	return parent.ParentSegmentID, parent.ParentSpanID, links
+}
+
+// exportTraceID derives a 16 bytes trace ID from the SkyWalking trace ID, which is kept in attrTraceID.
+func exportTraceID(traceID string) string {
+	sum := sha256.Sum256([]byte(traceID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// exportSpanID derives an 8 bytes span ID from the segment ID and the span ID within the segment.
+func exportSpanID(segmentID string, spanID int) string {
+	sum := sha256.Sum256([]byte(segmentID + "/" + strconv.Itoa(spanID)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func otlpKind(span *api.Span) int {
+	mq := stringValue(span.Layer) == "MQ"
+	switch span.Type {
+	case "Entry":
+		if mq {
+			return otlpKindConsumer
+		}
+		return otlpKindServer
+	case "Exit":
+		if mq {
+			return otlpKindProducer
+		}
+		return otlpKindClient
+	default:
+		return otlpKindInternal
+	}
+}
+
+func jaegerKind(kind int) string {
+	switch kind {
+	case otlpKindServer:
+		return "server"
+	case otlpKindClient:
+		return "client"
+	case otlpKindProducer:
+		return "producer"
+	case otlpKindConsumer:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) otlpKeyValue {
+	s := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &s}}
+}
+
+func jaegerString(key, value string) jaegerTag {
+	return jaegerTag{Key: key, Type: "string", Value: value}
+}
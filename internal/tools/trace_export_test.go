@@ -0,0 +1,205 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// exportTestTrace is a trace of two services, the second segment following an
+// exit span of the first one and a thread of a third segment.
+func exportTestTrace() *api.Trace {
+	root := testSpan("s1", 0, -1, 0, 100)
+	exit := exitSpan(testSpan("s1", 1, 0, 10, 90), "b:80", "Http")
+	entry := testSpan("s2", 0, -1, 20, 80,
+		testRef("s1", 1, api.RefTypeCrossProcess), testRef("s3", 2, api.RefTypeCrossThread))
+	entry.ServiceCode, entry.ServiceInstanceName, entry.Type = "b", "b-1", "Entry"
+	entry.AttachedEvents = []*api.SpanAttachedEvent{{
+		StartTime: &api.Instant{Seconds: 1, Nanos: 500000},
+		Event:     "retransmit",
+		Tags:      []*api.KeyValue{{Key: "reason", Value: optionalString("timeout")}},
+		Summary:   []*api.KeyNumericValue{{Key: "count", Value: 2}},
+	}}
+	return &api.Trace{Spans: []*api.Span{root, exit, entry}}
+}
+
+func TestExportParent(t *testing.T) {
+	crossProcess, crossThread := testRef("s1", 1, api.RefTypeCrossProcess), testRef("s3", 2, api.RefTypeCrossThread)
+	tests := []struct {
+		name          string
+		span          *api.Span
+		wantSegmentID string
+		wantSpanID    int
+		wantLinks     []*api.Ref
+	}{
+		{name: "root", span: testSpan("s1", 0, -1, 0, 100)},
+		{name: "child", span: testSpan("s1", 2, 1, 0, 100), wantSegmentID: "s1", wantSpanID: 1},
+		{name: "ref", span: testSpan("s2", 0, -1, 0, 100, crossProcess), wantSegmentID: "s1", wantSpanID: 1},
+		{
+			name:          "several refs",
+			span:          testSpan("s2", 0, -1, 0, 100, nil, crossProcess, crossThread),
+			wantSegmentID: "s1",
+			wantSpanID:    1,
+			wantLinks:     []*api.Ref{crossThread},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segmentID, spanID, links := exportParent(tt.span)
+			if segmentID != tt.wantSegmentID || spanID != tt.wantSpanID {
+				t.Errorf("parent = %s/%d, want %s/%d", segmentID, spanID, tt.wantSegmentID, tt.wantSpanID)
+			}
+			if !reflect.DeepEqual(links, tt.wantLinks) {
+				t.Errorf("links = %v, want %v", links, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestToOTLP(t *testing.T) {
+	result := toOTLP(exportTestTrace())
+	if len(result.ResourceSpans) != 2 {
+		t.Fatalf("resource spans = %d, want one per service instance", len(result.ResourceSpans))
+	}
+	traceID := exportTraceID("trace")
+	spans := make(map[string]*otlpSpan)
+	for _, resource := range result.ResourceSpans {
+		for _, span := range resource.ScopeSpans[0].Spans {
+			if span.TraceID != traceID {
+				t.Errorf("trace ID of %s = %s, want %s", span.Name, span.TraceID, traceID)
+			}
+			spans[span.Name] = span
+		}
+	}
+
+	tests := []struct {
+		name       string
+		wantSpanID string
+		wantParent string
+		wantKind   int
+	}{
+		{"s1/0", exportSpanID("s1", 0), "", otlpKindInternal},
+		{"s1/1", exportSpanID("s1", 1), exportSpanID("s1", 0), otlpKindClient},
+		{"s2/0", exportSpanID("s2", 0), exportSpanID("s1", 1), otlpKindServer},
+	}
+	for _, tt := range tests {
+		span := spans[tt.name]
+		if span == nil {
+			t.Fatalf("span %s not exported", tt.name)
+		}
+		if span.SpanID != tt.wantSpanID || span.ParentSpanID != tt.wantParent || span.Kind != tt.wantKind {
+			t.Errorf("span %s = %s parent %s kind %d, want %s parent %s kind %d",
+				tt.name, span.SpanID, span.ParentSpanID, span.Kind, tt.wantSpanID, tt.wantParent, tt.wantKind)
+		}
+	}
+
+	entry := spans["s2/0"]
+	attributes := make(map[string]string)
+	for _, kv := range entry.Attributes {
+		if kv.Value.StringValue != nil {
+			attributes[kv.Key] = *kv.Value.StringValue
+		} else {
+			attributes[kv.Key] = *kv.Value.IntValue
+		}
+	}
+	for key, want := range map[string]string{
+		attrTraceID:      "trace",
+		attrSegmentID:    "s2",
+		attrSpanID:       "0",
+		attrParentSpan:   "-1",
+		attrRefTraceID:   "trace",
+		attrRefSegmentID: "s1",
+		attrRefSpanID:    "1",
+		attrRefType:      string(api.RefTypeCrossProcess),
+	} {
+		if attributes[key] != want {
+			t.Errorf("attribute %s = %q, want %q", key, attributes[key], want)
+		}
+	}
+	if len(entry.Links) != 1 || entry.Links[0].SpanID != exportSpanID("s3", 2) {
+		t.Fatalf("links = %+v, want the cross thread ref", entry.Links)
+	}
+	if len(entry.Events) != 1 || entry.Events[0].Name != "retransmit" || entry.Events[0].TimeUnixNano != "1000500000" {
+		t.Errorf("events = %+v, want the attached event", entry.Events)
+	}
+}
+
+func TestToJaeger(t *testing.T) {
+	result := toJaeger(exportTestTrace())
+	trace := result.Data[0]
+	if trace.TraceID != exportTraceID("trace") {
+		t.Errorf("trace ID = %s, want %s", trace.TraceID, exportTraceID("trace"))
+	}
+	if len(trace.Processes) != 2 {
+		t.Errorf("processes = %d, want one per service instance", len(trace.Processes))
+	}
+
+	entry := trace.Spans[2]
+	wantReferences := []jaegerReference{
+		{RefType: "CHILD_OF", TraceID: exportTraceID("trace"), SpanID: exportSpanID("s1", 1)},
+		{RefType: "FOLLOWS_FROM", TraceID: exportTraceID("trace"), SpanID: exportSpanID("s3", 2)},
+	}
+	if !reflect.DeepEqual(entry.References, wantReferences) {
+		t.Errorf("references = %+v, want %+v", entry.References, wantReferences)
+	}
+	tags := make(map[string]any)
+	for _, tag := range entry.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	for key, want := range map[string]any{
+		"sw.link.1.trace_id":   "trace",
+		"sw.link.1.segment_id": "s3",
+		"sw.link.1.span_id":    2,
+		"sw.link.1.ref_type":   string(api.RefTypeCrossThread),
+		attrRefSegmentID:       "s1",
+		attrSpanID:             0,
+		"span.kind":            "server",
+	} {
+		if tags[key] != want {
+			t.Errorf("tag %s = %v, want %v", key, tags[key], want)
+		}
+	}
+
+	wantLogs := []jaegerLog{{Timestamp: 1000500, Fields: []jaegerTag{
+		jaegerString("event", "retransmit"),
+		jaegerString("reason", "timeout"),
+		{Key: "count", Type: "int64", Value: int64(2)},
+	}}}
+	if !reflect.DeepEqual(entry.Logs, wantLogs) {
+		t.Errorf("logs = %+v, want %+v", entry.Logs, wantLogs)
+	}
+}
+
+func TestExportTrace(t *testing.T) {
+	for _, format := range []string{FormatOTLP, FormatJaeger} {
+		exported, err := exportTrace(exportTestTrace(), format)
+		if err != nil {
+			t.Fatalf("export as %s: %v", format, err)
+		}
+		if !json.Valid([]byte(exported)) {
+			t.Errorf("export as %s is not valid JSON: %s", format, exported)
+		}
+	}
+	if _, err := exportTrace(exportTestTrace(), FormatTree); err == nil {
+		t.Error("export as tree succeeded, want an error")
+	}
+}
@@ -0,0 +1,216 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	defaultSampleSize       = 20
+	maxSampleSize           = 100
+	defaultFetchConcurrency = 5
+	maxFetchConcurrency     = 10
+	defaultOperationLimit   = 20
+)
+
+type LatencyBreakdownRequest struct {
+	TraceCondition
+	SampleSize  int `json:"sample_size"`
+	Concurrency int `json:"concurrency"`
+	Limit       int `json:"limit"`
+}
+
+// LatencyBreakdown aggregates the self time of the operations over sampled traces.
+type LatencyBreakdown struct {
+	SampledTraces int                `json:"sampled_traces"`
+	FailedTraces  int                `json:"failed_traces,omitempty"`
+	TraceDuration LatencyPercentiles `json:"trace_duration"`
+	Operations    []OperationLatency `json:"operations"`
+}
+
+// OperationLatency is the latency of an operation of a service. The self time
+// samples are the sum of the self time of its spans within each trace.
+type OperationLatency struct {
+	Service   string             `json:"service"`
+	Operation string             `json:"operation"`
+	Spans     int                `json:"spans"`
+	Traces    int                `json:"traces"`
+	SelfTime  LatencyPercentiles `json:"self_time"`
+	// SelfTimeShare is the fraction of the self time of all sampled traces spent in the operation.
+	SelfTimeShare float64 `json:"self_time_share"`
+}
+
+type LatencyPercentiles struct {
+	P50 int64 `json:"p50_ms"`
+	P95 int64 `json:"p95_ms"`
+	P99 int64 `json:"p99_ms"`
+}
+
+type operationKey struct {
+	service   string
+	operation string
+}
+
+func latencyBreakdown(ctx context.Context, req LatencyBreakdownRequest) (*LatencyBreakdown, error) {
+	sampleSize := min(req.SampleSize, maxSampleSize)
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	concurrency := min(req.Concurrency, maxFetchConcurrency)
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultOperationLimit
+	}
+
+	condition, err := req.toQueryCondition(ctx, 1, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	brief, err := trace.Traces(ctx, condition)
+	if err != nil {
+		return nil, fmt.Errorf("query traces failed: %w", err)
+	}
+
+	var traceIDs []string
+	seen := make(map[string]bool)
+	for _, t := range brief.Traces {
+		if len(t.TraceIds) > 0 && !seen[t.TraceIds[0]] {
+			seen[t.TraceIds[0]] = true
+			traceIDs = append(traceIDs, t.TraceIds[0])
+		}
+	}
+	if len(traceIDs) == 0 {
+		return nil, fmt.Errorf("no traces found for the given condition")
+	}
+
+	traces, failed, err := fetchTraces(ctx, traceIDs, concurrency)
+	if len(traces) == 0 {
+		return nil, fmt.Errorf("fetch the %d sampled traces failed: %w", len(traceIDs), err)
+	}
+
+	breakdown := aggregateLatency(traces, limit)
+	breakdown.FailedTraces = failed
+	return breakdown, nil
+}
+
+// fetchTraces queries the traces with at most concurrency queries in flight, returning
+// the traces fetched, the number of failures and the first of their errors.
+func fetchTraces(ctx context.Context, traceIDs []string, concurrency int) (traces []*api.Trace, failed int, err error) {
+	results := make([]*api.Trace, len(traceIDs))
+	errs := make([]error, len(traceIDs))
+	runConcurrently(len(traceIDs), concurrency, func(i int) {
+		results[i], errs[i] = fetchTrace(ctx, traceIDs[i])
+	})
+
+	for i, t := range results {
+		if errs[i] != nil {
+			failed++
+			if err == nil {
+				err = errs[i]
+			}
+			continue
+		}
+		traces = append(traces, t)
+	}
+	return traces, failed, err
+}
+
+// aggregateLatency computes the percentiles of the trace durations and of the
+// self time of each operation, keeping the limit operations with the largest share.
+func aggregateLatency(traces []*api.Trace, limit int) *LatencyBreakdown {
+	durations := make([]int64, 0, len(traces))
+	samples := make(map[operationKey][]int64)
+	spans := make(map[operationKey]int)
+	totals := make(map[operationKey]int64)
+	var total int64
+
+	for _, t := range traces {
+		tree := buildSpanTree(t)
+		durations = append(durations, tree.duration())
+
+		perTrace := make(map[operationKey]int64)
+		for _, node := range tree.ordered {
+			key := operationKey{node.span.ServiceCode, stringValue(node.span.EndpointName)}
+			perTrace[key] += node.selfTime
+			spans[key]++
+			total += node.selfTime
+		}
+		for key, selfTime := range perTrace {
+			samples[key] = append(samples[key], selfTime)
+			totals[key] += selfTime
+		}
+	}
+
+	operations := make([]OperationLatency, 0, len(samples))
+	for key, values := range samples {
+		operation := OperationLatency{
+			Service:   key.service,
+			Operation: key.operation,
+			Spans:     spans[key],
+			Traces:    len(values),
+			SelfTime:  newLatencyPercentiles(values),
+		}
+		if total > 0 {
+			operation.SelfTimeShare = float64(totals[key]) / float64(total)
+		}
+		operations = append(operations, operation)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].SelfTimeShare != operations[j].SelfTimeShare {
+			return operations[i].SelfTimeShare > operations[j].SelfTimeShare
+		}
+		return operations[i].Service+operations[i].Operation < operations[j].Service+operations[j].Operation
+	})
+
+	return &LatencyBreakdown{
+		SampledTraces: len(traces),
+		TraceDuration: newLatencyPercentiles(durations),
+		Operations:    operations[:min(limit, len(operations))],
+	}
+}
+
+func newLatencyPercentiles(values []int64) LatencyPercentiles {
+	sorted := sortedCopy(values)
+	return LatencyPercentiles{
+		P50: percentile(sorted, 50),
+		P95: percentile(sorted, 95),
+		P99: percentile(sorted, 99),
+	}
+}
+
+var LatencyBreakdownTool = NewTool[LatencyBreakdownRequest, *LatencyBreakdown](
+	"trace_latency_breakdown",
+	"Sample traces matching the condition, e.g. of an endpoint in a time window, and aggregate the self time of the spans "+
+		"by service and operation into p50/p95/p99, showing which calls typically dominate the latency",
+	latencyBreakdown,
+	mcp.WithTitleAnnotation("Aggregate the latency breakdown of sampled traces"),
+	WithTraceCondition(),
+	mcp.WithNumber("sample_size", mcp.Description("Number of traces to sample, defaults to 20, at most 100")),
+	mcp.WithNumber("concurrency", mcp.Description("Maximum number of traces fetched concurrently, defaults to 5, at most 10")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of operations returned, defaults to 20")),
+)
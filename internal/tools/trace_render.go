@@ -0,0 +1,91 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// TraceResult is a trace that can also be rendered as an indented span tree or exported as OTLP or Jaeger JSON.
+type TraceResult struct {
+	*api.Trace
+}
+
+func (t *TraceResult) Render(format string) (string, bool, error) {
+	switch format {
+	case FormatTree:
+		return renderSpanTree(buildSpanTree(t.Trace)), true, nil
+	case FormatOTLP, FormatJaeger:
+		text, err := exportTrace(t.Trace, format)
+		return text, true, err
+	default:
+		return "", false, nil
+	}
+}
+
+// renderSpanTree renders one line per span, indented by its depth in the tree.
+// Start offsets are relative to the earliest span of the trace.
+func renderSpanTree(tree *spanTree) string {
+	if len(tree.ordered) == 0 {
+		return "empty trace"
+	}
+	traceStart := tree.ordered[0].span.StartTime
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "trace %s: %d spans, %dms\n", tree.ordered[0].span.TraceID, len(tree.ordered), tree.duration())
+
+	var render func(node *spanNode, depth int)
+	render = func(node *spanNode, depth int) {
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(formatSpanLine(node.span, traceStart))
+		sb.WriteByte('\n')
+		for _, child := range node.children {
+			render(child, depth+1)
+		}
+	}
+	for _, root := range tree.roots {
+		render(root, 0)
+	}
+	return sb.String()
+}
+
+func formatSpanLine(span *api.Span, traceStart int64) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- [%s] %s", span.ServiceCode, stringValue(span.EndpointName))
+	var details []string
+	if span.Type != "" {
+		details = append(details, span.Type)
+	}
+	if component := stringValue(span.Component); component != "" {
+		details = append(details, component)
+	}
+	if peer := stringValue(span.Peer); peer != "" {
+		details = append(details, "peer="+peer)
+	}
+	if len(details) > 0 {
+		fmt.Fprintf(&sb, " (%s)", strings.Join(details, ", "))
+	}
+	fmt.Fprintf(&sb, " %dms +%dms", span.EndTime-span.StartTime, span.StartTime-traceStart)
+	if span.IsError != nil && *span.IsError {
+		sb.WriteString(" !ERROR")
+	}
+	return sb.String()
+}
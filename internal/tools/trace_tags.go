@@ -0,0 +1,123 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/skywalking-cli/pkg/graphql/client"
+	"github.com/machinebox/graphql"
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+const (
+	traceTagKeysQuery = `query ($duration: Duration!) {
+    result: queryTraceTagAutocompleteKeys(duration: $duration)
+}`
+	traceTagValuesQuery = `query ($tagKey: String!, $duration: Duration!) {
+    result: queryTraceTagAutocompleteValues(tagKey: $tagKey, duration: $duration)
+}`
+	// maxTagValueKeys bounds the keys whose values are listed, each one costing a query.
+	maxTagValueKeys      = 20
+	tagValuesConcurrency = 5
+)
+
+type TraceTagsRequest struct {
+	TimeRange
+	TagKey        string `json:"tag_key"`
+	IncludeValues bool   `json:"include_values"`
+}
+
+// TraceTags lists the tag keys of the traces and, if requested, their values. The values of the keys
+// beyond the first maxTagValueKeys are not listed, SkippedValueKeys telling how many keys were skipped.
+type TraceTags struct {
+	Keys             []string            `json:"keys"`
+	Values           map[string][]string `json:"values,omitempty"`
+	SkippedValueKeys int                 `json:"skipped_value_keys,omitempty"`
+}
+
+func listTraceTags(ctx context.Context, req TraceTagsRequest) (*TraceTags, error) {
+	duration, err := req.toDuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := &TraceTags{}
+	if req.TagKey != "" {
+		tags.Keys = []string{req.TagKey}
+	} else if tags.Keys, err = traceTagKeys(ctx, duration); err != nil {
+		return nil, err
+	}
+	if req.TagKey == "" && !req.IncludeValues {
+		return tags, nil
+	}
+
+	keys := tags.Keys[:min(len(tags.Keys), maxTagValueKeys)]
+	tags.SkippedValueKeys = len(tags.Keys) - len(keys)
+	values, errs := make([][]string, len(keys)), make([]error, len(keys))
+	runConcurrently(len(keys), tagValuesConcurrency, func(i int) {
+		values[i], errs[i] = traceTagValues(ctx, keys[i], duration)
+	})
+	tags.Values = make(map[string][]string, len(keys))
+	for i, key := range keys {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		tags.Values[key] = values[i]
+	}
+	return tags, nil
+}
+
+func traceTagKeys(ctx context.Context, duration api.Duration) ([]string, error) {
+	var response map[string][]string
+
+	request := graphql.NewRequest(traceTagKeysQuery)
+	request.Var("duration", duration)
+
+	if err := client.ExecuteQuery(ctx, request, &response); err != nil {
+		return nil, fmt.Errorf("query trace tag keys failed: %w", err)
+	}
+	return response["result"], nil
+}
+
+func traceTagValues(ctx context.Context, key string, duration api.Duration) ([]string, error) {
+	var response map[string][]string
+
+	request := graphql.NewRequest(traceTagValuesQuery)
+	request.Var("tagKey", key)
+	request.Var("duration", duration)
+
+	if err := client.ExecuteQuery(ctx, request, &response); err != nil {
+		return nil, fmt.Errorf("query values of trace tag %v failed: %w", key, err)
+	}
+	return response["result"], nil
+}
+
+var ListTraceTagsTool = NewTool[TraceTagsRequest, *TraceTags](
+	"list_trace_tags",
+	"List the span tag keys available for trace search in a time window, and their values, "+
+		"to build the tags filter of query_traces, e.g. http.status_code=500",
+	listTraceTags,
+	mcp.WithTitleAnnotation("List trace tag keys and values"),
+	mcp.WithString("tag_key", mcp.Description("Only list the values of this tag key")),
+	mcp.WithBoolean("include_values",
+		mcp.Description("List the values of the tag keys, of the first 20 keys at most, defaults to false")),
+	WithTimeRange(),
+)
@@ -0,0 +1,221 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// rpcLayers are the span layers whose exit calls are expected to reach an instrumented service.
+var rpcLayers = map[string]bool{
+	"Http":         true,
+	"RPCFramework": true,
+}
+
+// TraceValidation reports the gaps found by following the segment refs of a trace.
+type TraceValidation struct {
+	TraceID        string          `json:"trace_id"`
+	Complete       bool            `json:"complete"`
+	SegmentCount   int             `json:"segment_count"`
+	RootSegments   []string        `json:"root_segments"`
+	OrphanSpans    []OrphanSpan    `json:"orphan_spans,omitempty"`
+	MissingParents []MissingParent `json:"missing_parents,omitempty"`
+	ExitOnlyPeers  []ExitOnlyPeer  `json:"exit_only_peers,omitempty"`
+	Findings       []string        `json:"findings,omitempty"`
+}
+
+// OrphanSpan is a span whose parent span is missing from its own segment.
+type OrphanSpan struct {
+	SegmentID    string `json:"segment_id"`
+	SpanID       int    `json:"span_id"`
+	ParentSpanID int    `json:"parent_span_id"`
+	Service      string `json:"service"`
+	Endpoint     string `json:"endpoint,omitempty"`
+}
+
+// MissingParent is a segment ref pointing to a segment or span that is not part of the trace.
+type MissingParent struct {
+	SegmentID       string      `json:"segment_id"`
+	Service         string      `json:"service"`
+	Endpoint        string      `json:"endpoint,omitempty"`
+	ParentSegmentID string      `json:"parent_segment_id"`
+	ParentSpanID    int         `json:"parent_span_id"`
+	RefType         api.RefType `json:"ref_type"`
+	// SegmentExists tells whether only the span is missing from an existing parent segment.
+	SegmentExists bool `json:"segment_exists"`
+}
+
+// ExitOnlyPeer is a peer that is only seen from exit spans, no segment of the trace refers to those calls.
+type ExitOnlyPeer struct {
+	Peer      string   `json:"peer"`
+	Component string   `json:"component,omitempty"`
+	Layer     string   `json:"layer,omitempty"`
+	Callers   []string `json:"callers"`
+	Calls     int      `json:"calls"`
+	// ExpectInstrumented tells whether the layer of the calls usually reaches an instrumented service.
+	ExpectInstrumented bool `json:"expect_instrumented"`
+}
+
+func validateTrace(ctx context.Context, req TraceRequest) (*TraceValidation, error) {
+	traces, err := fetchTrace(ctx, req.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := checkSegmentRefs(buildSpanTree(traces))
+	validation.TraceID = req.TraceID
+	return validation, nil
+}
+
+// checkSegmentRefs follows the parent span IDs and the segment refs of all spans.
+func checkSegmentRefs(tree *spanTree) *TraceValidation {
+	validation := &TraceValidation{}
+	segments := make(map[string]bool)
+	referenced := make(map[spanKey]bool)
+	for _, node := range tree.ordered {
+		segments[node.span.SegmentID] = true
+		for _, ref := range node.span.Refs {
+			if ref != nil {
+				referenced[spanKey{ref.ParentSegmentID, ref.ParentSpanID}] = true
+			}
+		}
+	}
+	validation.SegmentCount = len(segments)
+
+	for _, node := range tree.ordered {
+		span := node.span
+		switch {
+		case span.ParentSpanID >= 0 && node.parent == nil:
+			validation.OrphanSpans = append(validation.OrphanSpans, OrphanSpan{
+				SegmentID:    span.SegmentID,
+				SpanID:       span.SpanID,
+				ParentSpanID: span.ParentSpanID,
+				Service:      span.ServiceCode,
+				Endpoint:     stringValue(span.EndpointName),
+			})
+		case span.ParentSpanID < 0 && len(span.Refs) == 0:
+			validation.RootSegments = append(validation.RootSegments, span.SegmentID)
+		case span.ParentSpanID < 0 && node.parent == nil:
+			for _, ref := range span.Refs {
+				if ref == nil {
+					continue
+				}
+				validation.MissingParents = append(validation.MissingParents, MissingParent{
+					SegmentID:       span.SegmentID,
+					Service:         span.ServiceCode,
+					Endpoint:        stringValue(span.EndpointName),
+					ParentSegmentID: ref.ParentSegmentID,
+					ParentSpanID:    ref.ParentSpanID,
+					RefType:         ref.Type,
+					SegmentExists:   segments[ref.ParentSegmentID],
+				})
+			}
+		}
+	}
+
+	validation.ExitOnlyPeers = findExitOnlyPeers(tree, referenced)
+	validation.Findings = summarizeValidation(validation)
+	validation.Complete = len(validation.OrphanSpans) == 0 && len(validation.MissingParents) == 0 &&
+		len(validation.RootSegments) <= 1
+	return validation
+}
+
+// findExitOnlyPeers groups the exit spans no segment refers to by their peer.
+func findExitOnlyPeers(tree *spanTree, referenced map[spanKey]bool) []ExitOnlyPeer {
+	peers := make(map[string]*ExitOnlyPeer)
+	var order []string
+	for _, node := range tree.ordered {
+		span := node.span
+		peer := stringValue(span.Peer)
+		if span.Type != "Exit" || peer == "" || referenced[spanKey{span.SegmentID, span.SpanID}] {
+			continue
+		}
+		exitPeer, ok := peers[peer]
+		if !ok {
+			layer := stringValue(span.Layer)
+			exitPeer = &ExitOnlyPeer{
+				Peer:               peer,
+				Component:          stringValue(span.Component),
+				Layer:              layer,
+				ExpectInstrumented: rpcLayers[layer],
+			}
+			peers[peer] = exitPeer
+			order = append(order, peer)
+		}
+		exitPeer.Calls++
+		if !slices.Contains(exitPeer.Callers, span.ServiceCode) {
+			exitPeer.Callers = append(exitPeer.Callers, span.ServiceCode)
+		}
+	}
+
+	result := make([]ExitOnlyPeer, 0, len(order))
+	for _, peer := range order {
+		result = append(result, *peers[peer])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ExpectInstrumented && !result[j].ExpectInstrumented
+	})
+	return result
+}
+
+func summarizeValidation(v *TraceValidation) []string {
+	var findings []string
+	if len(v.RootSegments) > 1 {
+		findings = append(findings, fmt.Sprintf("%d segments start without refs, the context was not propagated between them", len(v.RootSegments)))
+	}
+	for _, missing := range v.MissingParents {
+		switch {
+		case missing.SegmentExists:
+			findings = append(findings, fmt.Sprintf("segment %s of %s refers to span %d missing from segment %s, the parent segment is incomplete",
+				missing.SegmentID, missing.Service, missing.ParentSpanID, missing.ParentSegmentID))
+		case missing.RefType == api.RefTypeCrossThread:
+			findings = append(findings, fmt.Sprintf("segment %s of %s continues a thread of segment %s that was not reported, "+
+				"the application may not have finished it or the agent dropped it", missing.SegmentID, missing.Service, missing.ParentSegmentID))
+		default:
+			findings = append(findings, fmt.Sprintf("segment %s of %s is called from segment %s that was not reported, "+
+				"the caller may be uninstrumented or its agent dropped the segment", missing.SegmentID, missing.Service, missing.ParentSegmentID))
+		}
+	}
+	if len(v.OrphanSpans) > 0 {
+		findings = append(findings, fmt.Sprintf("%d spans miss their parent span within the segment, the segments were reported partially",
+			len(v.OrphanSpans)))
+	}
+	for _, peer := range v.ExitOnlyPeers {
+		if peer.ExpectInstrumented {
+			findings = append(findings, fmt.Sprintf("%s is called over %s by %v but reports no segment, the callee is likely uninstrumented",
+				peer.Peer, peer.Layer, peer.Callers))
+		}
+	}
+	return findings
+}
+
+var ValidateTraceTool = NewTool[TraceRequest, *TraceValidation](
+	"validate_trace",
+	"Validate the segment refs of a trace by its TraceId, reporting orphaned spans, refs to missing parent segments "+
+		"and peers that only appear in exit spans, to tell instrumentation gaps from application issues",
+	validateTrace,
+	mcp.WithTitleAnnotation("Validate the completeness of a trace"),
+	mcp.WithString("trace_id", mcp.Required(),
+		mcp.Description("The TraceId to validate")),
+)
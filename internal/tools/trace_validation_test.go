@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// exitSpan turns the span into an exit span calling the peer over the layer.
+func exitSpan(span *api.Span, peer, layer string) *api.Span {
+	span.Type, span.Peer, span.Layer = "Exit", &peer, &layer
+	return span
+}
+
+func TestCheckSegmentRefs(t *testing.T) {
+	tests := []struct {
+		name         string
+		spans        []*api.Span
+		wantComplete bool
+		wantRoots    []string
+		wantOrphans  []string
+		wantMissing  []MissingParent
+		wantPeers    []string
+		wantFindings []string
+	}{
+		{
+			name: "complete",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				exitSpan(testSpan("s1", 1, 0, 10, 90), "b:80", "Http"),
+				testSpan("s2", 0, -1, 20, 80, testRef("s1", 1, api.RefTypeCrossProcess)),
+			},
+			wantComplete: true,
+			wantRoots:    []string{"s1"},
+		},
+		{
+			name: "orphan span",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s1", 2, 1, 10, 20),
+			},
+			wantRoots:    []string{"s1"},
+			wantOrphans:  []string{"s1/2"},
+			wantFindings: []string{"1 spans miss their parent span"},
+		},
+		{
+			name: "missing parent segment",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s2", 0, -1, 10, 20, testRef("s9", 1, api.RefTypeCrossProcess)),
+			},
+			wantRoots: []string{"s1"},
+			wantMissing: []MissingParent{{
+				SegmentID: "s2", Service: "svc", Endpoint: "s2/0", ParentSegmentID: "s9", ParentSpanID: 1, RefType: api.RefTypeCrossProcess,
+			}},
+			wantFindings: []string{"is called from segment s9 that was not reported"},
+		},
+		{
+			name: "missing parent thread",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s2", 0, -1, 10, 20, testRef("s9", 1, api.RefTypeCrossThread)),
+			},
+			wantRoots: []string{"s1"},
+			wantMissing: []MissingParent{{
+				SegmentID: "s2", Service: "svc", Endpoint: "s2/0", ParentSegmentID: "s9", ParentSpanID: 1, RefType: api.RefTypeCrossThread,
+			}},
+			wantFindings: []string{"continues a thread of segment s9"},
+		},
+		{
+			name: "missing span of an existing segment",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s2", 0, -1, 10, 20, testRef("s1", 5, api.RefTypeCrossProcess)),
+			},
+			wantRoots: []string{"s1"},
+			wantMissing: []MissingParent{{
+				SegmentID: "s2", Service: "svc", Endpoint: "s2/0", ParentSegmentID: "s1", ParentSpanID: 5,
+				RefType: api.RefTypeCrossProcess, SegmentExists: true,
+			}},
+			wantFindings: []string{"refers to span 5 missing from segment s1"},
+		},
+		{
+			name: "several roots",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				testSpan("s2", 0, -1, 10, 20),
+			},
+			wantRoots:    []string{"s1", "s2"},
+			wantFindings: []string{"2 segments start without refs"},
+		},
+		{
+			name: "exit only peers",
+			spans: []*api.Span{
+				testSpan("s1", 0, -1, 0, 100),
+				exitSpan(testSpan("s1", 1, 0, 10, 20), "db:3306", "Database"),
+				exitSpan(testSpan("s1", 2, 0, 30, 40), "b:80", "Http"),
+				exitSpan(testSpan("s1", 3, 0, 50, 60), "b:80", "Http"),
+			},
+			wantComplete: true,
+			wantRoots:    []string{"s1"},
+			wantPeers:    []string{"b:80", "db:3306"},
+			wantFindings: []string{"b:80 is called over Http by [svc] but reports no segment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := checkSegmentRefs(buildSpanTree(&api.Trace{Spans: tt.spans}))
+			if v.Complete != tt.wantComplete {
+				t.Errorf("complete = %v, want %v", v.Complete, tt.wantComplete)
+			}
+			if !reflect.DeepEqual(v.RootSegments, tt.wantRoots) {
+				t.Errorf("root segments = %v, want %v", v.RootSegments, tt.wantRoots)
+			}
+			var orphans []string
+			for _, orphan := range v.OrphanSpans {
+				orphans = append(orphans, orphan.Endpoint)
+			}
+			if !reflect.DeepEqual(orphans, tt.wantOrphans) {
+				t.Errorf("orphan spans = %v, want %v", orphans, tt.wantOrphans)
+			}
+			if !reflect.DeepEqual(v.MissingParents, tt.wantMissing) {
+				t.Errorf("missing parents = %+v, want %+v", v.MissingParents, tt.wantMissing)
+			}
+			var peers []string
+			for _, peer := range v.ExitOnlyPeers {
+				peers = append(peers, peer.Peer)
+			}
+			if !reflect.DeepEqual(peers, tt.wantPeers) {
+				t.Errorf("exit only peers = %v, want %v", peers, tt.wantPeers)
+			}
+			if len(v.Findings) != len(tt.wantFindings) {
+				t.Fatalf("findings = %q, want %q", v.Findings, tt.wantFindings)
+			}
+			for i, finding := range v.Findings {
+				if !strings.Contains(finding, tt.wantFindings[i]) {
+					t.Errorf("finding %q does not contain %q", finding, tt.wantFindings[i])
+				}
+			}
+		})
+	}
+}
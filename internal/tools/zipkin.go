@@ -0,0 +1,412 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/contextkey"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	api "skywalking.apache.org/repo/goapi/query"
+)
+
+// DefaultZipkinPort and DefaultZipkinPath locate the Zipkin query API of OAP
+// when no Zipkin URL is configured, next to the GraphQL API.
+const (
+	DefaultZipkinPort = "9412"
+	DefaultZipkinPath = "/zipkin"
+)
+
+const defaultZipkinLimit = 10
+
+type zipkinURLKey struct{}
+
+// WithZipkinURL adds the base URL of the Zipkin query API to the context.
+func WithZipkinURL(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, zipkinURLKey{}, url)
+}
+
+// zipkinBaseURL returns the Zipkin URL of the context, or derives it from the OAP GraphQL URL.
+func zipkinBaseURL(ctx context.Context) (string, error) {
+	if u, ok := ctx.Value(zipkinURLKey{}).(string); ok && u != "" {
+		return strings.TrimRight(u, "/"), nil
+	}
+	baseURL, _ := ctx.Value(contextkey.BaseURL{}).(string)
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("cannot derive the Zipkin URL from the OAP URL %q", baseURL)
+	}
+	parsed.Host = net.JoinHostPort(parsed.Hostname(), DefaultZipkinPort)
+	parsed.Path = DefaultZipkinPath
+	return parsed.String(), nil
+}
+
+// zipkinGet queries the Zipkin v2 API and decodes the JSON response into out.
+func zipkinGet(ctx context.Context, path string, query url.Values, out any) error {
+	baseURL, err := zipkinBaseURL(ctx)
+	if err != nil {
+		return err
+	}
+	target := baseURL + "/api/v2" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return err
+	}
+	if authorization, ok := ctx.Value(contextkey.Authorization{}).(string); ok && authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("query zipkin %v failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("query zipkin %v failed: %s %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode zipkin %v response failed: %w", path, err)
+	}
+	return nil
+}
+
+// ZipkinSpan is a span of the Zipkin v2 model, timestamps and durations are in microseconds.
+type ZipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ID             string             `json:"id"`
+	ParentID       string             `json:"parentId,omitempty"`
+	Name           string             `json:"name,omitempty"`
+	Kind           string             `json:"kind,omitempty"`
+	Timestamp      int64              `json:"timestamp,omitempty"`
+	Duration       int64              `json:"duration,omitempty"`
+	Shared         bool               `json:"shared,omitempty"`
+	LocalEndpoint  *ZipkinEndpoint    `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *ZipkinEndpoint    `json:"remoteEndpoint,omitempty"`
+	Annotations    []ZipkinAnnotation `json:"annotations,omitempty"`
+	Tags           map[string]string  `json:"tags,omitempty"`
+}
+
+type ZipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type ZipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+func (e *ZipkinEndpoint) address() string {
+	if e == nil {
+		return ""
+	}
+	host := e.IPv4
+	if host == "" {
+		host = e.IPv6
+	}
+	if host == "" || e.Port == 0 {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(e.Port))
+}
+
+type ZipkinSpanNamesRequest struct {
+	ServiceName string `json:"service_name"`
+}
+
+type ZipkinTracesRequest struct {
+	TimeRange
+	ServiceName     string `json:"service_name"`
+	SpanName        string `json:"span_name"`
+	AnnotationQuery string `json:"annotation_query"`
+	MinDuration     int64  `json:"min_duration"`
+	MaxDuration     int64  `json:"max_duration"`
+	Limit           int    `json:"limit"`
+}
+
+func listZipkinServices(ctx context.Context, _ struct{}) ([]string, error) {
+	var services []string
+	if err := zipkinGet(ctx, "/services", nil, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+func listZipkinSpanNames(ctx context.Context, req ZipkinSpanNamesRequest) ([]string, error) {
+	if req.ServiceName == "" {
+		return nil, fmt.Errorf("service_name is required")
+	}
+	var names []string
+	if err := zipkinGet(ctx, "/spans", url.Values{"serviceName": {req.ServiceName}}, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func queryZipkinTraces(ctx context.Context, req ZipkinTracesRequest) ([]TraceSummary, error) {
+	start, end, _, err := req.bounds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultZipkinLimit
+	}
+
+	query := url.Values{
+		"endTs":    {strconv.FormatInt(end.UnixMilli(), 10)},
+		"lookback": {strconv.FormatInt(end.Sub(start).Milliseconds(), 10)},
+		"limit":    {strconv.Itoa(limit)},
+	}
+	for key, value := range map[string]string{
+		"serviceName":     req.ServiceName,
+		"spanName":        req.SpanName,
+		"annotationQuery": req.AnnotationQuery,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	// durations are given in milliseconds while Zipkin expects microseconds
+	if req.MinDuration > 0 {
+		query.Set("minDuration", strconv.FormatInt(req.MinDuration*1000, 10))
+	}
+	if req.MaxDuration > 0 {
+		query.Set("maxDuration", strconv.FormatInt(req.MaxDuration*1000, 10))
+	}
+
+	var traces [][]ZipkinSpan
+	if err := zipkinGet(ctx, "/traces", query, &traces); err != nil {
+		return nil, err
+	}
+	location := clockOf(ctx).location
+	summaries := make([]TraceSummary, 0, len(traces))
+	for _, spans := range traces {
+		if len(spans) > 0 {
+			summaries = append(summaries, summarizeZipkinTrace(spans, location))
+		}
+	}
+	return summaries, nil
+}
+
+func searchZipkinTrace(ctx context.Context, req SearchTraceRequest) (*TraceResult, error) {
+	var spans []ZipkinSpan
+	if err := zipkinGet(ctx, "/trace/"+url.PathEscape(req.TraceID), nil, &spans); err != nil {
+		return nil, err
+	}
+	return &TraceResult{Trace: convertZipkinSpans(spans)}, nil
+}
+
+// summarizeZipkinTrace builds the same summary as the native trace list, the root span
+// being the span without a parent, or the earliest one.
+func summarizeZipkinTrace(spans []ZipkinSpan, location *time.Location) TraceSummary {
+	root := &spans[0]
+	start, end := root.Timestamp, root.Timestamp+root.Duration
+	isError := false
+	for i := range spans {
+		span := &spans[i]
+		spanIsRoot, rootIsRoot := span.ParentID == "", root.ParentID == ""
+		if spanIsRoot && !rootIsRoot || spanIsRoot == rootIsRoot && span.Timestamp < root.Timestamp {
+			root = span
+		}
+		start, end = min(start, span.Timestamp), max(end, span.Timestamp+span.Duration)
+		if _, ok := span.Tags["error"]; ok {
+			isError = true
+		}
+	}
+	return TraceSummary{
+		TraceIDs:      []string{root.TraceID},
+		EndpointNames: []string{root.Name},
+		Duration:      int((end - start) / 1000),
+		Start:         formatMillis(strconv.FormatInt(start/1000, 10), location),
+		IsError:       isError,
+	}
+}
+
+// zipkinSharedSuffix tells apart the segment of the server half of a span whose ID is shared with the client.
+const zipkinSharedSuffix = "-server"
+
+// isSharedZipkinSpan tells whether the span is the server half of a span ID shared with the client,
+// which older instrumentations report without the shared flag.
+func isSharedZipkinSpan(span *ZipkinSpan, spansByID map[string]int) bool {
+	return span.Shared || span.Kind == "SERVER" && spansByID[span.ID] > 1
+}
+
+// zipkinSegments returns the segment of each span and of its parent. The server half of a shared
+// span gets a segment of its own under the client half, and is the parent of the spans referring to the ID.
+func zipkinSegments(zipkinSpans []ZipkinSpan) (segmentIDs, parentSegmentIDs []string) {
+	spansByID := make(map[string]int, len(zipkinSpans))
+	for i := range zipkinSpans {
+		spansByID[zipkinSpans[i].ID]++
+	}
+	sharedIDs := make(map[string]bool)
+	for i := range zipkinSpans {
+		if isSharedZipkinSpan(&zipkinSpans[i], spansByID) {
+			sharedIDs[zipkinSpans[i].ID] = true
+		}
+	}
+
+	segmentIDs, parentSegmentIDs = make([]string, len(zipkinSpans)), make([]string, len(zipkinSpans))
+	for i := range zipkinSpans {
+		zs := &zipkinSpans[i]
+		segmentIDs[i], parentSegmentIDs[i] = zs.ID, zs.ParentID
+		if sharedIDs[zs.ParentID] {
+			parentSegmentIDs[i] += zipkinSharedSuffix
+		}
+		if isSharedZipkinSpan(zs, spansByID) {
+			segmentIDs[i] += zipkinSharedSuffix
+			if spansByID[zs.ID] > 1 {
+				parentSegmentIDs[i] = zs.ID
+			}
+		}
+	}
+	return segmentIDs, parentSegmentIDs
+}
+
+// convertZipkinSpans maps Zipkin spans to the native trace model. Zipkin has no
+// segments, so every span becomes a segment of its own referring to its parent span.
+func convertZipkinSpans(zipkinSpans []ZipkinSpan) *api.Trace {
+	segmentIDs, parentSegmentIDs := zipkinSegments(zipkinSpans)
+	trace := &api.Trace{Spans: make([]*api.Span, 0, len(zipkinSpans))}
+	for i := range zipkinSpans {
+		zs := &zipkinSpans[i]
+		name := zs.Name
+		span := &api.Span{
+			TraceID:      zs.TraceID,
+			SegmentID:    segmentIDs[i],
+			SpanID:       0,
+			ParentSpanID: -1,
+			StartTime:    zs.Timestamp / 1000,
+			EndTime:      (zs.Timestamp + zs.Duration) / 1000,
+			EndpointName: &name,
+			Type:         zipkinSpanType(zs.Kind),
+			Tags:         make([]*api.KeyValue, 0, len(zs.Tags)),
+		}
+		if zs.LocalEndpoint != nil {
+			span.ServiceCode = zs.LocalEndpoint.ServiceName
+			span.ServiceInstanceName = zs.LocalEndpoint.address()
+		}
+		if parentSegmentIDs[i] != "" {
+			span.Refs = []*api.Ref{{TraceID: zs.TraceID, ParentSegmentID: parentSegmentIDs[i], Type: api.RefTypeCrossProcess}}
+		}
+		if peer := zs.RemoteEndpoint.address(); peer != "" {
+			span.Peer = &peer
+		} else if zs.RemoteEndpoint != nil && zs.RemoteEndpoint.ServiceName != "" {
+			span.Peer = &zs.RemoteEndpoint.ServiceName
+		}
+
+		keys := make([]string, 0, len(zs.Tags))
+		for key := range zs.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := zs.Tags[key]
+			span.Tags = append(span.Tags, &api.KeyValue{Key: key, Value: &value})
+		}
+		if _, ok := zs.Tags["error"]; ok {
+			isError := true
+			span.IsError = &isError
+		}
+		for _, annotation := range zs.Annotations {
+			value := annotation.Value
+			span.Logs = append(span.Logs, &api.LogEntity{
+				Time: annotation.Timestamp / 1000,
+				Data: []*api.KeyValue{{Key: "event", Value: &value}},
+			})
+		}
+		trace.Spans = append(trace.Spans, span)
+	}
+	return trace
+}
+
+func zipkinSpanType(kind string) string {
+	switch kind {
+	case "SERVER", "CONSUMER":
+		return "Entry"
+	case "CLIENT", "PRODUCER":
+		return "Exit"
+	default:
+		return "Local"
+	}
+}
+
+func AddZipkinTools(mcp *server.MCPServer) {
+	ListZipkinServicesTool.Register(mcp)
+	ListZipkinSpanNamesTool.Register(mcp)
+	QueryZipkinTracesTool.Register(mcp)
+	SearchZipkinTraceTool.Register(mcp)
+}
+
+var ListZipkinServicesTool = NewTool[struct{}, []string](
+	"zipkin_list_services",
+	"List the services reporting Zipkin spans to OAP",
+	listZipkinServices,
+	mcp.WithTitleAnnotation("List Zipkin services"),
+)
+
+var ListZipkinSpanNamesTool = NewTool[ZipkinSpanNamesRequest, []string](
+	"zipkin_list_span_names",
+	"List the span names of a service reporting Zipkin spans to OAP",
+	listZipkinSpanNames,
+	mcp.WithTitleAnnotation("List Zipkin span names"),
+	mcp.WithString("service_name", mcp.Required(),
+		mcp.Description("The name of the Zipkin service")),
+)
+
+var QueryZipkinTracesTool = NewTool[ZipkinTracesRequest, []TraceSummary](
+	"zipkin_query_traces",
+	"Query Zipkin traces from OAP by service, span name, annotations and duration in a time window",
+	queryZipkinTraces,
+	mcp.WithTitleAnnotation("Query Zipkin traces"),
+	mcp.WithString("service_name", mcp.Description("The name of the Zipkin service")),
+	mcp.WithString("span_name", mcp.Description("The name of the span")),
+	mcp.WithString("annotation_query", mcp.Description("Zipkin annotation query, e.g. 'error and http.method=GET'")),
+	mcp.WithNumber("min_duration", mcp.Description("Minimum trace duration in milliseconds")),
+	mcp.WithNumber("max_duration", mcp.Description("Maximum trace duration in milliseconds")),
+	mcp.WithNumber("limit", mcp.Description("Maximum number of traces, defaults to 10")),
+	WithTimeRange(),
+)
+
+var SearchZipkinTraceTool = NewTool[SearchTraceRequest, *TraceResult](
+	"zipkin_search_trace_by_trace_id",
+	"Search for a Zipkin trace by its TraceId, optionally rendered as a span tree or exported as OTLP or Jaeger JSON",
+	searchZipkinTrace,
+	mcp.WithTitleAnnotation("Search a Zipkin trace by TraceId"),
+	mcp.WithString("trace_id", mcp.Required(),
+		mcp.Description("The Zipkin TraceId to search for")),
+	WithOutputFormat(FormatTree, FormatOTLP, FormatJaeger),
+)
@@ -0,0 +1,218 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/skywalking-cli/pkg/contextkey"
+)
+
+const zipkinTraceJSON = `[
+  {"traceId": "463ac35c9f6413ad", "id": "a2fb4a1d1a96d312", "parentId": "463ac35c9f6413ad", "name": "get /users",
+   "kind": "CLIENT", "timestamp": 1767607200150000, "duration": 50000,
+   "localEndpoint": {"serviceName": "frontend", "ipv4": "10.0.0.1", "port": 8080},
+   "remoteEndpoint": {"serviceName": "backend"}, "tags": {"http.path": "/users", "error": "timeout"}},
+  {"traceId": "463ac35c9f6413ad", "id": "463ac35c9f6413ad", "name": "get /", "kind": "SERVER",
+   "timestamp": 1767607200100000, "duration": 200000, "localEndpoint": {"serviceName": "frontend"},
+   "annotations": [{"timestamp": 1767607200120000, "value": "ws"}]}
+]`
+
+// zipkinServer serves the trace on the traces and trace APIs of Zipkin, and records the query of the last request.
+func zipkinServer(t *testing.T) (ctx context.Context, query func() url.Values) {
+	t.Helper()
+	var last url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r.URL.Query()
+		switch r.URL.Path {
+		case "/zipkin/api/v2/traces":
+			fmt.Fprintf(w, "[%s]", zipkinTraceJSON)
+		case "/zipkin/api/v2/trace/463ac35c9f6413ad":
+			fmt.Fprint(w, zipkinTraceJSON)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// the clock of the OAP is cached so that the time range does not query it
+	oapURL := server.URL + "/graphql"
+	oapClocks.Store(oapURL, &oapClock{location: time.UTC, expiresAt: time.Now().Add(time.Hour)})
+	t.Cleanup(func() { oapClocks.Delete(oapURL) })
+
+	ctx = context.WithValue(context.Background(), contextkey.BaseURL{}, oapURL)
+	return WithZipkinURL(ctx, server.URL+"/zipkin/"), func() url.Values { return last }
+}
+
+func TestQueryZipkinTraces(t *testing.T) {
+	ctx, query := zipkinServer(t)
+	summaries, err := queryZipkinTraces(ctx, ZipkinTracesRequest{
+		TimeRange:   TimeRange{Start: "2026-01-05T09:00:00Z", End: "2026-01-05T10:00:00Z"},
+		ServiceName: "frontend",
+		MinDuration: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TraceSummary{{
+		TraceIDs:      []string{"463ac35c9f6413ad"},
+		EndpointNames: []string{"get /"},
+		Duration:      200,
+		Start:         "2026-01-05 10:00:00.100",
+		IsError:       true,
+	}}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("summaries = %+v, want %+v", summaries, want)
+	}
+
+	wantQuery := url.Values{
+		"serviceName": {"frontend"},
+		"endTs":       {"1767607200000"},
+		"lookback":    {"3600000"},
+		"limit":       {"10"},
+		"minDuration": {"100000"},
+	}
+	if got := query(); !reflect.DeepEqual(got, wantQuery) {
+		t.Errorf("query = %v, want %v", got, wantQuery)
+	}
+}
+
+func TestSearchZipkinTrace(t *testing.T) {
+	ctx, _ := zipkinServer(t)
+	result, err := searchZipkinTrace(ctx, SearchTraceRequest{TraceRequest: TraceRequest{TraceID: "463ac35c9f6413ad"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := result.Trace.Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	client, root := spans[0], spans[1]
+	if root.SegmentID != "463ac35c9f6413ad" || root.Type != "Entry" || len(root.Refs) != 0 {
+		t.Errorf("root span = %+v, want an entry segment without refs", root)
+	}
+	if root.StartTime != 1767607200100 || root.EndTime != 1767607200300 {
+		t.Errorf("root span lasts from %d to %d, want milliseconds", root.StartTime, root.EndTime)
+	}
+	if len(root.Logs) != 1 || root.Logs[0].Time != 1767607200120 || *root.Logs[0].Data[0].Value != "ws" {
+		t.Errorf("root span logs = %+v, want the annotation", root.Logs)
+	}
+
+	if client.Type != "Exit" || client.ServiceCode != "frontend" || client.ServiceInstanceName != "10.0.0.1:8080" {
+		t.Errorf("client span = %+v, want an exit span of frontend at 10.0.0.1:8080", client)
+	}
+	if client.Peer == nil || *client.Peer != "backend" {
+		t.Errorf("client span peer = %v, want backend", client.Peer)
+	}
+	if len(client.Refs) != 1 || client.Refs[0].ParentSegmentID != "463ac35c9f6413ad" {
+		t.Errorf("client span refs = %+v, want the root segment", client.Refs)
+	}
+	if client.IsError == nil || !*client.IsError {
+		t.Error("client span is not an error")
+	}
+	var keys []string
+	for _, tag := range client.Tags {
+		keys = append(keys, tag.Key)
+	}
+	if want := []string{"error", "http.path"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("client span tags = %v, want sorted %v", keys, want)
+	}
+}
+
+func TestZipkinGetStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr []string
+	}{
+		{name: "not found", status: http.StatusNotFound, body: "no such trace\n", wantErr: []string{"404 Not Found", "no such trace"}},
+		{name: "unavailable", status: http.StatusServiceUnavailable, body: "", wantErr: []string{"503 Service Unavailable"}},
+		{name: "long body", status: http.StatusInternalServerError, body: strings.Repeat("x", 1024), wantErr: []string{"500", strings.Repeat("x", 512)}},
+		{name: "invalid json", status: http.StatusOK, body: "<html>", wantErr: []string{"decode zipkin /services response failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			var services []string
+			err := zipkinGet(WithZipkinURL(context.Background(), server.URL), "/services", nil, &services)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+			if strings.Contains(err.Error(), strings.Repeat("x", 513)) {
+				t.Errorf("error %q has the whole body", err)
+			}
+		})
+	}
+}
+
+func TestConvertZipkinSharedSpans(t *testing.T) {
+	// the client and server halves of a call share the span ID b, the server span c calls the database
+	tests := []struct {
+		name   string
+		shared bool
+	}{
+		{name: "shared flag", shared: true},
+		{name: "without shared flag", shared: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := []ZipkinSpan{
+				{TraceID: "t", ID: "a", Name: "root", Kind: "SERVER", Timestamp: 0, Duration: 100000},
+				{TraceID: "t", ID: "b", ParentID: "a", Name: "call", Kind: "CLIENT", Timestamp: 10000, Duration: 80000},
+				{TraceID: "t", ID: "b", ParentID: "a", Name: "handle", Kind: "SERVER", Timestamp: 20000, Duration: 60000, Shared: tt.shared},
+				{TraceID: "t", ID: "c", ParentID: "b", Name: "query", Kind: "CLIENT", Timestamp: 30000, Duration: 10000},
+			}
+			tree := buildSpanTree(convertZipkinSpans(spans))
+			if len(tree.nodes) != len(spans) {
+				t.Fatalf("tree has %d nodes, want %d", len(tree.nodes), len(spans))
+			}
+
+			parents := make(map[string]string)
+			for _, node := range tree.ordered {
+				if node.parent != nil {
+					parents[*node.span.EndpointName] = *node.parent.span.EndpointName
+				}
+			}
+			want := map[string]string{"call": "root", "handle": "call", "query": "handle"}
+			if !reflect.DeepEqual(parents, want) {
+				t.Errorf("parents = %v, want %v", parents, want)
+			}
+		})
+	}
+}